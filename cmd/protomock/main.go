@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	stdlog "log"
@@ -11,20 +13,35 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/uptrace/bunrouter"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/sknv/protomock/internal/config"
 	"github.com/sknv/protomock/internal/container"
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/internal/scenario"
+	"github.com/sknv/protomock/internal/store"
+	transportAdmin "github.com/sknv/protomock/internal/transport/admin"
 	transportGRPC "github.com/sknv/protomock/internal/transport/grpc"
 	transportHTTP "github.com/sknv/protomock/internal/transport/http"
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/grpc/codec"
 	ctxloggermw "github.com/sknv/protomock/pkg/grpc/middleware/ctxlogger"
 	loggermw "github.com/sknv/protomock/pkg/grpc/middleware/logger"
 	requestidmw "github.com/sknv/protomock/pkg/grpc/middleware/requestid"
 	"github.com/sknv/protomock/pkg/http/middleware"
+	"github.com/sknv/protomock/pkg/js"
 	"github.com/sknv/protomock/pkg/log"
+	"github.com/sknv/protomock/pkg/net/conntrack"
+	"github.com/sknv/protomock/pkg/option"
 	"github.com/sknv/protomock/pkg/os"
+	xtls "github.com/sknv/protomock/pkg/tls"
+	"github.com/sknv/protomock/pkg/watcher"
 )
 
-const _stopTimeout = time.Second * 10
+const (
+	_stopTimeout   = time.Second * 10
+	_watchInterval = time.Second
+)
 
 func main() {
 	configPath := config.FilePathFlag()
@@ -71,32 +88,128 @@ func buildApp(ctx context.Context, cfg *config.Config) (*container.Application,
 	logger := app.RegisterLogger(log.Config{Level: cfg.Log.Level})
 	slog.SetDefault(logger) // Sets the global default logger.
 
+	// Journal of the handled calls, it is only queried by the admin server.
+	jrnl := option.None[*journal.Journal]()
+	if cfg.AdminServer.Enabled {
+		jrnl = option.Some(journal.New(cfg.AdminServer.JournalSize))
+	}
+
+	// Store shared across all the mocks.
+	mocksStore, err := buildStore(app, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build store: %w", err)
+	}
+
+	// Scenarios shared across all the mocks.
+	scenarios := scenario.New()
+
+	// Fixtures shared across all the mocks.
+	fixtures := js.NewFixtures(cfg.Fixtures.Dir)
+	app.AddCloser(func(context.Context) error {
+		return fixtures.Close()
+	})
+
+	// Runtimes reused by all the mocks.
+	runtimes := js.NewPool(cfg.Scripts.PoolSize, cfg.Scripts.MaxStackDepth, js.Globals{
+		"store":    mocksStore.ScriptAPI(),
+		"scenario": scenarios.Scenario,
+		"fixtures": fixtures,
+	})
+
 	// HTTP server.
+	httpHandlers := option.None[*transportHTTP.Handlers]()
+
 	if cfg.HTTPServer.Enabled {
-		if err := buildHTTPServer(app, cfg); err != nil {
+		handlers, err := buildHTTPServer(ctx, app, cfg, jrnl, runtimes)
+		if err != nil {
 			return nil, fmt.Errorf("build http server: %w", err)
 		}
+
+		httpHandlers = option.Some(handlers)
 	}
 
 	// GRPC server.
+	grpcHandlers := option.None[*transportGRPC.Handlers]()
+
 	if cfg.GRPCServer.Enabled {
-		if err := buildGRPServer(ctx, app, cfg); err != nil {
+		handlers, err := buildGRPServer(ctx, app, cfg, jrnl, runtimes)
+		if err != nil {
 			return nil, fmt.Errorf("build grpc server: %w", err)
 		}
+
+		grpcHandlers = option.Some(handlers)
+	}
+
+	// Admin server.
+	if cfg.AdminServer.Enabled {
+		buildAdminServer(app, cfg, jrnl.Unwrap(), mocksStore, scenarios, httpHandlers, grpcHandlers)
 	}
 
 	return app, nil
 }
 
-//nolint:contextcheck,nolintlint // false positive
-func buildHTTPServer(app *container.Application, cfg *config.Config) error {
-	mocks, err := transportHTTP.BuildMocks(cfg.HTTPServer.MocksDir)
+// buildStore creates a store restoring it from the snapshot file if one is configured.
+func buildStore(app *container.Application, cfg *config.Config) (*store.Store, error) {
+	mocksStore := store.New()
+
+	snapshotFile := cfg.Store.SnapshotFile
+	if snapshotFile == "" {
+		return mocksStore, nil
+	}
+
+	if err := mocksStore.Load(snapshotFile); err != nil {
+		return nil, fmt.Errorf("load store snapshot: %w", err)
+	}
+
+	// Remember to save the snapshot after the servers are stopped.
+	app.AddCloser(func(context.Context) error {
+		if err := mocksStore.Save(snapshotFile); err != nil {
+			return fmt.Errorf("save store snapshot: %w", err)
+		}
+
+		return nil
+	})
+
+	return mocksStore, nil
+}
+
+func buildHTTPServer(
+	ctx context.Context,
+	app *container.Application,
+	cfg *config.Config,
+	jrnl option.Option[*journal.Journal],
+	runtimes *js.Pool,
+) (*transportHTTP.Handlers, error) {
+	mocks, err := transportHTTP.BuildMocks(cfg.HTTPServer.MocksDir, cfg.HTTPServer.LibDir)
+	if err != nil {
+		return nil, fmt.Errorf("build http mocks: %w", err)
+	}
+
+	upstream, recorder, err := buildHTTPUpstream(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build http upstream: %w", err)
+	}
+
+	faults, err := fault.ParseAll(cfg.HTTPServer.Faults)
+	if err != nil {
+		return nil, fmt.Errorf("parse http faults: %w", err)
+	}
+
+	timeouts := js.Timeouts{Default: cfg.Scripts.Timeout, ByKey: cfg.HTTPServer.ScriptTimeouts}
+
+	handlers, err := transportHTTP.NewHandlers(mocks, jrnl, runtimes, upstream, recorder, faults, timeouts)
+	if err != nil {
+		return nil, fmt.Errorf("build http handlers: %w", err)
+	}
+
+	tlsConfig, err := buildTLSConfig(cfg.HTTPServer.TLS)
 	if err != nil {
-		return fmt.Errorf("build http mocks: %w", err)
+		return nil, fmt.Errorf("build http tls config: %w", err)
 	}
 
 	router := app.RegisterHTTPServer(
 		fmt.Sprintf(":%d", cfg.HTTPServer.Port),
+		tlsConfig,
 		bunrouter.Use(
 			middleware.ProvideContextLogger(app.Logger().Unwrap()),
 			middleware.ProvideRequestID,
@@ -107,33 +220,267 @@ func buildHTTPServer(app *container.Application, cfg *config.Config) error {
 		),
 	)
 
-	handlers := transportHTTP.NewHandlers(mocks)
 	handlers.Route(router)
 
-	return nil
+	// Reload the mocks on change.
+	if cfg.HTTPServer.Watch {
+		dirs := watchedDirs(cfg.HTTPServer.MocksDir, cfg.HTTPServer.LibDir)
+		go watcher.Watch(ctx, dirs, _watchInterval, func(ctx context.Context) {
+			reloadHTTPMocks(ctx, handlers, cfg.HTTPServer.MocksDir, cfg.HTTPServer.LibDir)
+		})
+	}
+
+	return handlers, nil
 }
 
-func buildGRPServer(ctx context.Context, app *container.Application, cfg *config.Config) error {
-	packages, err := transportGRPC.BuildPackages(ctx, cfg.GRPCServer.MocksDir)
+// buildHTTPUpstream creates an upstream for the unmatched requests if one is configured
+// along with a recorder of its responses if the record mode is enabled.
+func buildHTTPUpstream(
+	cfg *config.Config,
+) (option.Option[*transportHTTP.Upstream], option.Option[*transportHTTP.Recorder], error) {
+	var (
+		upstream = option.None[*transportHTTP.Upstream]()
+		recorder = option.None[*transportHTTP.Recorder]()
+	)
+
+	if cfg.HTTPServer.Upstream == "" {
+		if cfg.HTTPServer.Record {
+			return upstream, recorder, errors.New("upstream is required to record http mocks")
+		}
+
+		return upstream, recorder, nil
+	}
+
+	httpUpstream, err := transportHTTP.NewUpstream(cfg.HTTPServer.Upstream)
 	if err != nil {
-		return fmt.Errorf("build grpc packages: %w", err)
+		return upstream, recorder, fmt.Errorf("create http upstream: %w", err)
 	}
 
-	server := app.RegisterGRPCServer(
-		fmt.Sprintf(":%d", cfg.GRPCServer.Port),
-		grpc.ChainUnaryInterceptor(
-			ctxloggermw.ProvideUnaryContextLogger(app.Logger().Unwrap()),
-			requestidmw.ProvideUnaryRequestID,
-			ctxloggermw.ProvideUnaryLogRequestID,
-			loggermw.LogUnaryRequest,
-			recovery.UnaryServerInterceptor(),
-		),
+	upstream = option.Some(httpUpstream)
+
+	if cfg.HTTPServer.Record {
+		recorder = option.Some(transportHTTP.NewRecorder(cfg.HTTPServer.MocksDir))
+	}
+
+	return upstream, recorder, nil
+}
+
+// watchedDirs returns the mocks dir along with the lib dir if it is set, so that the shared modules
+// are reloaded on change too.
+func watchedDirs(mocksDir, libDir string) []string {
+	if libDir == "" {
+		return []string{mocksDir} // The default lib dir is inside the mocks one.
+	}
+
+	return []string{mocksDir, libDir}
+}
+
+// reloadHTTPMocks rebuilds the mocks keeping the previous ones in case of any error.
+func reloadHTTPMocks(ctx context.Context, handlers *transportHTTP.Handlers, mocksDir, libDir string) {
+	logger := log.FromContext(ctx)
+
+	mocks, err := transportHTTP.BuildMocks(mocksDir, libDir)
+	if err != nil {
+		logger.ErrorContext(ctx, "Can't reload http mocks", slog.Any("error", err))
+
+		return
+	}
+
+	if err = handlers.Reload(mocks); err != nil {
+		logger.ErrorContext(ctx, "Can't reload http mocks", slog.Any("error", err))
+
+		return
+	}
+
+	logger.InfoContext(ctx, "Http mocks reloaded", slog.Int("mocks", len(mocks)))
+}
+
+func buildGRPServer(
+	ctx context.Context,
+	app *container.Application,
+	cfg *config.Config,
+	jrnl option.Option[*journal.Journal],
+	runtimes *js.Pool,
+) (*transportGRPC.Handlers, error) {
+	packages, err := transportGRPC.BuildPackages(ctx, cfg.GRPCServer.MocksDir, cfg.GRPCServer.LibDir)
+	if err != nil {
+		return nil, fmt.Errorf("build grpc packages: %w", err)
+	}
+
+	registry := packages.Registry(ctx)
+
+	upstream, recorder, err := buildGRPCUpstream(app, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build grpc upstream: %w", err)
+	}
+
+	faults, err := fault.ParseAll(cfg.GRPCServer.Faults)
+	if err != nil {
+		return nil, fmt.Errorf("parse grpc faults: %w", err)
+	}
+
+	tlsConfig, err := buildTLSConfig(cfg.GRPCServer.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("build grpc tls config: %w", err)
+	}
+
+	timeouts := js.Timeouts{Default: cfg.Scripts.Timeout, ByKey: cfg.GRPCServer.ScriptTimeouts}
+	conns := conntrack.NewTracker()
+
+	handlers, err := transportGRPC.NewHandlers(
+		packages, registry, jrnl, runtimes, upstream, recorder, faults, timeouts, conns,
 	)
+	if err != nil {
+		return nil, fmt.Errorf("build grpc handlers: %w", err)
+	}
+
+	// All the calls, unary ones included, are served as streams by the unknown service handler,
+	// so only the stream interceptors apply.
+	opts := []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(
+			ctxloggermw.ProvideStreamContextLogger(app.Logger().Unwrap()),
+			requestidmw.ProvideStreamRequestID,
+			ctxloggermw.ProvideStreamLogRequestID,
+			loggermw.LogStreamRequest,
+			recovery.StreamServerInterceptor(),
+		),
+		grpc.UnknownServiceHandler(handlers.Handle),  // Serves all the mocks.
+		grpc.ForceServerCodecV2(codec.NewRawCodec()), // Allows to proxy the unmocked calls as is.
+	}
+	if tlsConfig.IsSome() {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig.Unwrap())))
+	}
+
+	server := app.RegisterGRPCServer(fmt.Sprintf(":%d", cfg.GRPCServer.Port), conns, opts...)
 
-	handlers := transportGRPC.NewHandlers(packages)
 	handlers.Route(server)
 
-	return nil
+	// Reload the mocks on change.
+	if cfg.GRPCServer.Watch {
+		dirs := watchedDirs(cfg.GRPCServer.MocksDir, cfg.GRPCServer.LibDir)
+		go watcher.Watch(ctx, dirs, _watchInterval, func(ctx context.Context) {
+			reloadGRPCMocks(ctx, handlers, cfg.GRPCServer.MocksDir, cfg.GRPCServer.LibDir)
+		})
+	}
+
+	return handlers, nil
+}
+
+// buildGRPCUpstream creates an upstream for the unmocked calls if one is configured
+// along with a recorder of its responses if the record mode is enabled.
+func buildGRPCUpstream(
+	app *container.Application,
+	cfg *config.Config,
+) (option.Option[*transportGRPC.Upstream], option.Option[*transportGRPC.Recorder], error) {
+	var (
+		upstream = option.None[*transportGRPC.Upstream]()
+		recorder = option.None[*transportGRPC.Recorder]()
+	)
+
+	if cfg.GRPCServer.Upstream == "" {
+		if cfg.GRPCServer.Record {
+			return upstream, recorder, errors.New("upstream is required to record grpc mocks")
+		}
+
+		return upstream, recorder, nil
+	}
+
+	grpcUpstream, err := transportGRPC.NewUpstream(cfg.GRPCServer.Upstream)
+	if err != nil {
+		return upstream, recorder, fmt.Errorf("create grpc upstream: %w", err)
+	}
+
+	// Remember to close the upstream connection.
+	app.AddCloser(func(context.Context) error {
+		if err := grpcUpstream.Close(); err != nil {
+			return fmt.Errorf("close grpc upstream: %w", err)
+		}
+
+		return nil
+	})
+
+	upstream = option.Some(grpcUpstream)
+
+	if cfg.GRPCServer.Record {
+		recorder = option.Some(transportGRPC.NewRecorder(cfg.GRPCServer.MocksDir))
+	}
+
+	return upstream, recorder, nil
+}
+
+// reloadGRPCMocks rebuilds the mocks keeping the previous ones in case of any error.
+func reloadGRPCMocks(ctx context.Context, handlers *transportGRPC.Handlers, mocksDir, libDir string) {
+	logger := log.FromContext(ctx)
+
+	packages, err := transportGRPC.BuildPackages(ctx, mocksDir, libDir)
+	if err != nil {
+		logger.ErrorContext(ctx, "Can't reload grpc mocks", slog.Any("error", err))
+
+		return
+	}
+
+	if err = handlers.Reload(packages, packages.Registry(ctx)); err != nil {
+		logger.ErrorContext(ctx, "Can't reload grpc mocks", slog.Any("error", err))
+
+		return
+	}
+
+	logger.InfoContext(ctx, "Grpc mocks reloaded", slog.Int("packages", len(packages)))
+}
+
+// buildTLSConfig creates a server TLS config if TLS is enabled,
+// the certificate is issued by a self-signed CA in the auto mode.
+func buildTLSConfig(cfg config.TLSConfig) (option.Option[*tls.Config], error) {
+	if !cfg.Enabled {
+		return option.None[*tls.Config](), nil
+	}
+
+	if !cfg.Auto {
+		tlsConfig, err := xtls.NewServerConfig(cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile)
+		if err != nil {
+			return option.None[*tls.Config](), fmt.Errorf("build server config: %w", err)
+		}
+
+		return option.Some(tlsConfig), nil
+	}
+
+	authority, err := xtls.LoadOrCreateAuthority(cfg.AutoCADir)
+	if err != nil {
+		return option.None[*tls.Config](), fmt.Errorf("load certificate authority: %w", err)
+	}
+
+	tlsConfig, err := authority.NewServerConfig(cfg.ClientCAFile)
+	if err != nil {
+		return option.None[*tls.Config](), fmt.Errorf("build auto server config: %w", err)
+	}
+
+	return option.Some(tlsConfig), nil
+}
+
+//nolint:contextcheck,nolintlint // false positive
+func buildAdminServer(
+	app *container.Application,
+	cfg *config.Config,
+	jrnl *journal.Journal,
+	mocksStore *store.Store,
+	scenarios *scenario.Scenarios,
+	httpHandlers option.Option[*transportHTTP.Handlers],
+	grpcHandlers option.Option[*transportGRPC.Handlers],
+) {
+	router := app.RegisterAdminServer(
+		fmt.Sprintf(":%d", cfg.AdminServer.Port),
+		bunrouter.Use(
+			middleware.ProvideContextLogger(app.Logger().Unwrap()),
+			middleware.ProvideRequestID,
+			middleware.ProvideLogRequestID,
+			middleware.LogRequest,
+			middleware.HandleError,
+			middleware.Recover,
+		),
+	)
+
+	handlers := transportAdmin.NewHandlers(jrnl, mocksStore, scenarios, httpHandlers, grpcHandlers)
+	handlers.Route(router)
 }
 
 // stopApp tries to stop the app gracefully.
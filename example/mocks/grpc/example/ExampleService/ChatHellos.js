@@ -0,0 +1,26 @@
+let count = 0
+
+function onMessage(msg, stream) {
+  count++
+
+  if (!msg.name) {
+    stream.close({
+      code: 3,
+      message: "Name is required"
+    })
+
+    return
+  }
+
+  stream.send({ message: `Hello, ${msg.name}` })
+}
+
+function onEnd(stream) {
+  stream.send({
+    message: "Goodbye",
+    details: {
+      code: count,
+      status: "OK"
+    }
+  })
+}
@@ -0,0 +1,13 @@
+(function () {
+  let names = request.messages.map((msg) => msg.name)
+
+  return {
+    body: {
+      message: `Hello, ${names.join(", ")}`,
+      details: {
+        code: request.messages.length,
+        status: "OK"
+      }
+    }
+  }
+})()
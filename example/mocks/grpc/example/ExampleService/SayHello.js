@@ -6,12 +6,42 @@
     return {
       error: {
         code: 3,
-        message: "Invalid argument"
+        message: "Invalid argument",
+        details: [
+          {
+            type: "google.rpc.BadRequest",
+            body: {
+              fieldViolations: [
+                {
+                  field: "name",
+                  description: "Name is invalid"
+                }
+              ]
+            }
+          },
+          {
+            type: "example.Details",
+            body: {
+              code: 3,
+              status: "INVALID"
+            }
+          }
+        ]
       }
     }
   }
 
+  let greetings = store.increment(`greetings:${request.body.name}`)
+
   return {
+    headers: {
+      "x-server-version": "1.0.0",
+      "x-greetings-count": `${greetings}`
+    },
+    trailers: {
+      "x-next-cursor": "abc",
+      "x-checksum-bin": "AQID" // Binary values are base64 encoded
+    },
     body: {
       message: `Hello, ${request.body.name}, your role is ${request.body.role}`,
       details: {
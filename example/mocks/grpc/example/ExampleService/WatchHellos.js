@@ -0,0 +1,26 @@
+(function () {
+  // Send a message right away using the stream callback.
+  stream.send({ message: `Hello, ${request.body.name}` })
+
+  // Or return a list of messages to send with optional delays in milliseconds.
+  return {
+    messages: [
+      {
+        body: {
+          message: `How are you, ${request.body.name}?`
+        },
+        delay: 100
+      },
+      {
+        body: {
+          message: `Goodbye, ${request.body.name}`,
+          details: {
+            code: 1,
+            status: "OK"
+          }
+        },
+        delay: 200
+      }
+    ]
+  }
+})()
@@ -0,0 +1,21 @@
+(function () {
+  // Respond with plain text for text requests.
+  if (request.contentType.startsWith("text/")) {
+    return {
+      status: 200,
+      contentType: "text/plain; charset=utf-8",
+      body: `You said: ${request.body}`
+    }
+  }
+
+  // Respond with JSON otherwise.
+  return {
+    status: 200,
+    body: {
+      contentType: request.contentType,
+      body: request.body,
+      files: request.files,
+      rawBody: request.rawBody
+    }
+  }
+})()
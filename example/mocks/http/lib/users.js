@@ -0,0 +1,11 @@
+// Shared helpers, required by the mocks as require("./lib/users") or just require("users").
+
+function newUser(id, name, surname) {
+  return {
+    id: `${id}`,
+    name: name ?? "John",
+    surname: surname ?? "Doe"
+  }
+}
+
+module.exports = { newUser }
@@ -0,0 +1,8 @@
+(function () {
+  return {
+    status: 200,
+    body: {
+      status: scenario("checkout").state
+    }
+  }
+})()
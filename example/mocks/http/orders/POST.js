@@ -0,0 +1,10 @@
+(function () {
+  scenario("checkout").transition("created")
+
+  return {
+    status: 201,
+    body: {
+      status: "created"
+    }
+  }
+})()
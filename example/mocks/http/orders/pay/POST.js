@@ -0,0 +1,20 @@
+(function () {
+  let checkout = scenario("checkout")
+  if (checkout.state !== "created") {
+    return {
+      status: 409,
+      body: {
+        error: `Can't pay for an order in ${checkout.state} state`
+      }
+    }
+  }
+
+  checkout.transition("paid")
+
+  return {
+    status: 200,
+    body: {
+      status: checkout.state
+    }
+  }
+})()
@@ -0,0 +1,14 @@
+(function () {
+  let products = fixtures.load("products/list.yaml") // A fresh copy on every call, so it's safe to tweak
+
+  if (request.query.inStock === "true") {
+    products = products.filter((product) => product.inStock)
+  }
+
+  return {
+    status: 200,
+    body: {
+      products: products
+    }
+  }
+})()
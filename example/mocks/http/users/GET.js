@@ -1,17 +1,16 @@
 (function () {
   console.log("Incoming headers are", JSON.stringify(request.headers))
+  console.log(`Handling ${request.method} ${request.url} via ${request.route} route`)
+
+  let page = Number(request.query.page ?? 1)
 
   return {
     status: 200,
+    delay: { distribution: "lognormal", median: 50, sigma: 0.5, max: 500 }, // Realistic latency in milliseconds
     body: {
       users: {
-        list: [
-          {
-            id: "1",
-            name: "John",
-            surname: "Doe"
-          }
-        ]
+        page: page,
+        list: store.list("users:")
       }
     }
   }
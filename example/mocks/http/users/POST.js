@@ -1,18 +1,28 @@
 (function () {
-  let name = request.body.name ?? "John"
-  let surname = request.body.surname ?? "Doe"
+  const { newUser } = require("./lib/users")
+
+  let id = `${store.increment("counters:users")}`
+  let user = newUser(id, request.body.name, request.body.surname)
+
+  store.set(`users:${id}`, user)
 
   return {
     status: 201,
+    headers: {
+      "Location": `/users/${id}`,
+      "Cache-Control": ["no-cache", "no-store"]
+    },
+    cookies: [
+      {
+        name: "session",
+        value: "secret",
+        path: "/",
+        httpOnly: true
+      }
+    ],
     body: {
       users: {
-        list: [
-          {
-            id: "1",
-            name: name,
-            surname: surname
-          }
-        ]
+        list: [user]
       }
     }
   }
@@ -0,0 +1,9 @@
+(function () {
+  console.log("Deleting user", request.params.user_id)
+
+  store.delete(`users:${request.params.user_id}`)
+
+  return {
+    status: 204
+  }
+})()
@@ -1,14 +1,22 @@
 (function () {
+  const { newUser } = require("users")
+
+  let user = store.get(`users:${request.params.user_id}`)
+  if (user) {
+    return {
+      status: 200,
+      body: {
+        user: user
+      }
+    }
+  }
+
   let name = request.headers["test-case-name"] ?? "John"
 
   return {
     status: 200,
     body: {
-      user: {
-        id: `${request.params.user_id}`,
-        name: name,
-        surname: "Doe"
-      }
+      user: newUser(request.params.user_id, name)
     }
   }
 })()
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/sknv/protomock/pkg/config"
 )
@@ -20,22 +21,69 @@ type LogConfig struct {
 	Level slog.Level `yaml:"level" envconfig:"LOG_LEVEL"`
 }
 
+type TLSConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	CertFile     string `yaml:"certfile"`
+	KeyFile      string `yaml:"keyfile"`
+	ClientCAFile string `yaml:"clientcafile"` // Enables mutual TLS if set.
+	Auto         bool   `yaml:"auto"`         // Issue a certificate by a self-signed CA.
+	AutoCADir    string `yaml:"autocadir"`    // Where the self-signed CA is kept.
+}
+
 type HTTPServerConfig struct {
-	Enabled  bool   `yaml:"enabled" envconfig:"HTTP_SERVER_ENABLED"`
-	Port     int    `yaml:"port" envconfig:"HTTP_SERVER_PORT"`
-	MocksDir string `yaml:"mocksdir" envconfig:"HTTP_SERVER_MOCKSDIR"`
+	Enabled        bool                     `yaml:"enabled" envconfig:"HTTP_SERVER_ENABLED"`
+	Port           int                      `yaml:"port" envconfig:"HTTP_SERVER_PORT"`
+	MocksDir       string                   `yaml:"mocksdir" envconfig:"HTTP_SERVER_MOCKSDIR"`
+	LibDir         string                   `yaml:"libdir" envconfig:"HTTP_SERVER_LIBDIR"` // Shared modules, <mocksdir>/lib by default.
+	Watch          bool                     `yaml:"watch" envconfig:"HTTP_SERVER_WATCH"`
+	Upstream       string                   `yaml:"upstream" envconfig:"HTTP_SERVER_UPSTREAM"` // Base URL of a real service.
+	Record         bool                     `yaml:"record" envconfig:"HTTP_SERVER_RECORD"`     // Record the unmatched requests to the upstream.
+	Faults         map[string]any           `yaml:"faults" ignored:"true"`                     // Default faults by routes, e.g. GET /users/:user_id.
+	ScriptTimeouts map[string]time.Duration `yaml:"scripttimeouts" ignored:"true"`             // Script timeouts by routes overriding the default one.
+	TLS            TLSConfig                `yaml:"tls" ignored:"true"`
 }
 
 type GRPCServerConfig struct {
-	Enabled  bool   `yaml:"enabled" envconfig:"GRPC_SERVER_ENABLED"`
-	Port     int    `yaml:"port" envconfig:"GRPC_SERVER_PORT"`
-	MocksDir string `yaml:"mocksdir" envconfig:"GRPC_SERVER_MOCKSDIR"`
+	Enabled        bool                     `yaml:"enabled" envconfig:"GRPC_SERVER_ENABLED"`
+	Port           int                      `yaml:"port" envconfig:"GRPC_SERVER_PORT"`
+	MocksDir       string                   `yaml:"mocksdir" envconfig:"GRPC_SERVER_MOCKSDIR"`
+	LibDir         string                   `yaml:"libdir" envconfig:"GRPC_SERVER_LIBDIR"` // Shared modules, <mocksdir>/lib by default.
+	Watch          bool                     `yaml:"watch" envconfig:"GRPC_SERVER_WATCH"`
+	Upstream       string                   `yaml:"upstream" envconfig:"GRPC_SERVER_UPSTREAM"` // Address of a real service.
+	Record         bool                     `yaml:"record" envconfig:"GRPC_SERVER_RECORD"`     // Record the unmocked unary calls to the upstream.
+	Faults         map[string]any           `yaml:"faults" ignored:"true"`                     // Default faults by full methods, e.g. /example.ExampleService/SayHello.
+	ScriptTimeouts map[string]time.Duration `yaml:"scripttimeouts" ignored:"true"`             // Script timeouts by full methods overriding the default one.
+	TLS            TLSConfig                `yaml:"tls" ignored:"true"`
+}
+
+type AdminServerConfig struct {
+	Enabled     bool `yaml:"enabled" envconfig:"ADMIN_SERVER_ENABLED"`
+	Port        int  `yaml:"port" envconfig:"ADMIN_SERVER_PORT"`
+	JournalSize int  `yaml:"journalsize" envconfig:"ADMIN_SERVER_JOURNALSIZE"` // Max number of the journaled calls.
+}
+
+type StoreConfig struct {
+	SnapshotFile string `yaml:"snapshotfile" envconfig:"STORE_SNAPSHOTFILE"` // Loaded on startup and saved on shutdown if set.
+}
+
+type FixturesConfig struct {
+	Dir string `yaml:"dir" envconfig:"FIXTURES_DIR"` // Root of the files loaded by the scripts.
+}
+
+type ScriptsConfig struct {
+	PoolSize      int           `yaml:"poolsize" envconfig:"SCRIPTS_POOLSIZE"`           // Max number of idle runtimes, the number of CPUs by default.
+	Timeout       time.Duration `yaml:"timeout" envconfig:"SCRIPTS_TIMEOUT"`             // Max run time of a script, unlimited if zero.
+	MaxStackDepth int           `yaml:"maxstackdepth" envconfig:"SCRIPTS_MAXSTACKDEPTH"` // Max call stack depth, 1000 by default.
 }
 
 type Config struct {
-	Log        LogConfig        `yaml:"log"`
-	HTTPServer HTTPServerConfig `yaml:"httpserver"`
-	GRPCServer GRPCServerConfig `yaml:"grpcserver"`
+	Log         LogConfig         `yaml:"log"`
+	HTTPServer  HTTPServerConfig  `yaml:"httpserver"`
+	GRPCServer  GRPCServerConfig  `yaml:"grpcserver"`
+	AdminServer AdminServerConfig `yaml:"adminserver"`
+	Store       StoreConfig       `yaml:"store"`
+	Fixtures    FixturesConfig    `yaml:"fixtures"`
+	Scripts     ScriptsConfig     `yaml:"scripts"`
 }
 
 func Parse(filePath string) (*Config, error) {
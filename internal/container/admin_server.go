@@ -0,0 +1,61 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"log/slog"
+	"net/http"
+
+	"github.com/uptrace/bunrouter"
+
+	"github.com/sknv/protomock/pkg/option"
+)
+
+func (a *Application) RegisterAdminServer(address string, opts ...bunrouter.Option) *bunrouter.Router {
+	router := bunrouter.New(opts...)
+	adminServer := &httpServer{
+		router: router,
+		server: newHTTPServer(address, router),
+	}
+
+	a.adminServer = option.Some(adminServer)
+
+	return router
+}
+
+// ----------------------------------------------------------------------------
+
+func (a *Application) runAdminServer(ctx context.Context) error {
+	if a.adminServer.IsNone() {
+		return nil // No admin server registered.
+	}
+
+	logger := a.logger.UnwrapOrElse(slog.Default)
+	server := a.adminServer.Unwrap().server
+
+	logger.InfoContext(ctx, "Starting admin server...", slog.String("address", server.Addr))
+	defer logger.InfoContext(ctx, "Admin server started")
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			stdlog.Fatalf("Can't start admin server: %v", err)
+		}
+	}()
+
+	// Remember to stop the server.
+	a.closers.Add(func(closeCtx context.Context) error {
+		logger.InfoContext(closeCtx, "Stopping admin server...")
+
+		if err := server.Shutdown(closeCtx); err != nil {
+			return fmt.Errorf("shutdown admin server: %w", err)
+		}
+
+		logger.InfoContext(closeCtx, "Admin server stopped")
+
+		return nil
+	})
+
+	return nil
+}
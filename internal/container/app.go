@@ -12,18 +12,20 @@ import (
 )
 
 type Application struct {
-	closers    *closer.Closers
-	logger     option.Option[*slog.Logger]
-	httpServer option.Option[*httpServer]
-	grpcServer option.Option[*grpcServer]
+	closers     *closer.Closers
+	logger      option.Option[*slog.Logger]
+	httpServer  option.Option[*httpServer]
+	grpcServer  option.Option[*grpcServer]
+	adminServer option.Option[*httpServer]
 }
 
 func NewApplication() *Application {
 	return &Application{
-		closers:    closer.New(),
-		logger:     option.None[*slog.Logger](),
-		httpServer: option.None[*httpServer](),
-		grpcServer: option.None[*grpcServer](),
+		closers:     closer.New(),
+		logger:      option.None[*slog.Logger](),
+		httpServer:  option.None[*httpServer](),
+		grpcServer:  option.None[*grpcServer](),
+		adminServer: option.None[*httpServer](),
 	}
 }
 
@@ -34,6 +36,7 @@ func (a *Application) Run(ctx context.Context) error {
 	if err := runParallel(ctx,
 		a.runHTTPServer,
 		a.runGRPCServer,
+		a.runAdminServer,
 	); err != nil {
 		return fmt.Errorf("run components in parallel: %w", err)
 	}
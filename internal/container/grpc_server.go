@@ -10,19 +10,24 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/sknv/protomock/pkg/closer"
+	"github.com/sknv/protomock/pkg/net/conntrack"
 	"github.com/sknv/protomock/pkg/option"
 )
 
 type grpcServer struct {
 	address string
+	conns   *conntrack.Tracker // Tracks the accepted connections, e.g. to break them on purpose.
 	server  *grpc.Server
 }
 
-func (a *Application) RegisterGRPCServer(address string, opts ...grpc.ServerOption) *grpc.Server {
+func (a *Application) RegisterGRPCServer(
+	address string, conns *conntrack.Tracker, opts ...grpc.ServerOption,
+) *grpc.Server {
 	server := grpc.NewServer(opts...)
 
 	grpcServer := &grpcServer{
 		address: address,
+		conns:   conns,
 		server:  server,
 	}
 
@@ -59,6 +64,8 @@ func (a *Application) runGRPCServer(ctx context.Context) error {
 		return fmt.Errorf("listen tcp address: %w", err)
 	}
 
+	lis = grpcServer.conns.Listener(lis)
+
 	go func() {
 		if err := grpcServer.server.Serve(lis); err != nil {
 			stdlog.Fatalf("Can't start grpc server: %v", err)
@@ -2,6 +2,7 @@ package container
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	stdlog "log"
@@ -19,12 +20,15 @@ type httpServer struct {
 	server *http.Server
 }
 
-func (a *Application) RegisterHTTPServer(address string, opts ...bunrouter.Option) *bunrouter.Router {
+func (a *Application) RegisterHTTPServer(
+	address string, tlsConfig option.Option[*tls.Config], opts ...bunrouter.Option,
+) *bunrouter.Router {
 	router := bunrouter.New(opts...)
 	httpServer := &httpServer{
 		router: router,
 		server: newHTTPServer(address, router),
 	}
+	httpServer.server.TLSConfig = tlsConfig.UnwrapOr(nil)
 
 	a.httpServer = option.Some(httpServer)
 
@@ -53,6 +57,15 @@ func newHTTPServer(address string, handler http.Handler) *http.Server {
 	}
 }
 
+// listenAndServe serves TLS if the server has a TLS config, the certificates are expected to be in the config.
+func listenAndServe(server *http.Server) error {
+	if server.TLSConfig != nil {
+		return server.ListenAndServeTLS("", "") //nolint:wrapcheck // proxy
+	}
+
+	return server.ListenAndServe() //nolint:wrapcheck // proxy
+}
+
 func (a *Application) runHTTPServer(ctx context.Context) error {
 	if a.httpServer.IsNone() {
 		return nil // No HTTP server registered.
@@ -61,11 +74,14 @@ func (a *Application) runHTTPServer(ctx context.Context) error {
 	logger := a.logger.UnwrapOrElse(slog.Default)
 	server := a.httpServer.Unwrap().server
 
-	logger.InfoContext(ctx, "Starting http server...", slog.String("address", server.Addr))
+	logger.InfoContext(ctx, "Starting http server...",
+		slog.String("address", server.Addr),
+		slog.Bool("tls", server.TLSConfig != nil),
+	)
 	defer logger.InfoContext(ctx, "Http server started")
 
 	go func() {
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := listenAndServe(server); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			stdlog.Fatalf("Can't start http server: %v", err)
 		}
 	}()
@@ -0,0 +1,79 @@
+package journal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Filter matches journal entries, empty fields match any entry.
+type Filter struct {
+	Protocol  string
+	Method    string
+	Route     string
+	Path      string
+	Status    *int
+	RequestID string
+	Request   map[string]string // Dot separated paths inside the request to the expected values, e.g. body.id -> 42.
+}
+
+func (f Filter) Match(entry Entry) bool {
+	switch {
+	case f.Protocol != "" && f.Protocol != entry.Protocol,
+		f.Method != "" && f.Method != entry.Method,
+		f.Route != "" && f.Route != entry.Route,
+		f.Path != "" && f.Path != entry.Path,
+		f.Status != nil && *f.Status != entry.Status,
+		f.RequestID != "" && f.RequestID != entry.RequestID:
+		return false
+	}
+
+	for path, expected := range f.Request {
+		value, ok := lookup(entry.Request, strings.Split(path, "."))
+		if !ok || !equal(value, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lookup finds a nested value by the path keys, list items are addressed by indexes.
+func lookup(value any, keys []string) (any, bool) {
+	for _, key := range keys {
+		switch val := value.(type) {
+		case map[string]any:
+			next, ok := val[key]
+			if !ok {
+				return nil, false
+			}
+
+			value = next
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nil, false
+			}
+
+			value = val[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// equal compares a normalized JSON value with its string representation.
+func equal(value any, expected string) bool {
+	switch val := value.(type) {
+	case string:
+		return val == expected
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64) == expected
+	case nil:
+		return expected == "null"
+	default:
+		return fmt.Sprint(val) == expected
+	}
+}
@@ -0,0 +1,115 @@
+package journal
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sknv/protomock/pkg/document"
+)
+
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+const _defaultCapacity = 1000
+
+// Entry describes a handled call.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Protocol  string    `json:"protocol"` // Either http or grpc.
+	Method    string    `json:"method"`   // HTTP method or gRPC full method, e.g. /example.ExampleService/SayHello.
+	Route     string    `json:"route"`    // Matched HTTP route, e.g. /users/:user_id, or gRPC full method.
+	Path      string    `json:"path"`     // Requested HTTP path or gRPC full method.
+	Status    int       `json:"status"`   // HTTP status or gRPC code.
+	LatencyMs int64     `json:"latencyMs"`
+	RequestID string    `json:"requestId"`
+	Request   any       `json:"request"`  // Decoded request as seen by the mock script.
+	Response  any       `json:"response"` // Response as returned by the mock script.
+}
+
+// Journal keeps the latest handled calls in memory, the oldest entries are dropped when the capacity is reached.
+type Journal struct {
+	mu       sync.RWMutex
+	entries  []Entry
+	start    int // Index of the oldest entry.
+	capacity int
+}
+
+// New returns a journal of the provided capacity, a default one is used if it is not positive.
+func New(capacity int) *Journal {
+	if capacity <= 0 {
+		capacity = _defaultCapacity
+	}
+
+	return &Journal{
+		mu:       sync.RWMutex{},
+		entries:  make([]Entry, 0, capacity),
+		start:    0,
+		capacity: capacity,
+	}
+}
+
+// Record adds an entry to the journal, the request and response are normalized to plain JSON values
+// to be matched by filters.
+func (j *Journal) Record(entry Entry) {
+	entry.Request = normalize(entry.Request)
+	entry.Response = normalize(entry.Response)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.entries) < j.capacity {
+		j.entries = append(j.entries, entry)
+
+		return
+	}
+
+	// Overwrite the oldest entry.
+	j.entries[j.start] = entry
+	j.start = (j.start + 1) % j.capacity
+}
+
+// Find returns the entries matching the filter in the order they were recorded.
+func (j *Journal) Find(filter Filter) []Entry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := make([]Entry, 0)
+
+	for i := range j.entries {
+		entry := j.entries[(j.start+i)%len(j.entries)]
+		if filter.Match(entry) {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// Count returns the number of entries matching the filter.
+func (j *Journal) Count(filter Filter) int {
+	return len(j.Find(filter))
+}
+
+// Reset removes all the entries.
+func (j *Journal) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = slices.Delete(j.entries, 0, len(j.entries))
+	j.start = 0
+}
+
+// ----------------------------------------------------------------------------
+
+// normalize drops a value which can't be normalized, the journal keeps the rest of the entry anyway.
+func normalize(value any) any {
+	result, err := document.Normalize(value)
+	if err != nil {
+		return nil
+	}
+
+	return result
+}
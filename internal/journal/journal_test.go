@@ -0,0 +1,111 @@
+package journal
+
+import (
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	t.Parallel()
+
+	ok, notFound := 200, 404
+
+	entry := Entry{ //nolint:exhaustruct
+		Protocol:  ProtocolHTTP,
+		Method:    "POST",
+		Route:     "/users/:user_id",
+		Path:      "/users/42",
+		Status:    ok,
+		RequestID: "req-1",
+		Request: normalize(map[string]any{
+			"body": map[string]any{
+				"id":     42,
+				"name":   "John",
+				"admin":  false,
+				"note":   nil,
+				"emails": []string{"john@example.com"},
+			},
+		}),
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty filter", filter: Filter{}, want: true}, //nolint:exhaustruct
+		{name: "all fields", filter: Filter{
+			Protocol:  ProtocolHTTP,
+			Method:    "POST",
+			Route:     "/users/:user_id",
+			Path:      "/users/42",
+			Status:    &ok,
+			RequestID: "req-1",
+			Request:   nil,
+		}, want: true},
+		{name: "other protocol", filter: Filter{Protocol: ProtocolGRPC}, want: false},                                            //nolint:exhaustruct
+		{name: "other method", filter: Filter{Method: "GET"}, want: false},                                                       //nolint:exhaustruct
+		{name: "other route", filter: Filter{Route: "/users"}, want: false},                                                      //nolint:exhaustruct
+		{name: "other path", filter: Filter{Path: "/users/1"}, want: false},                                                      //nolint:exhaustruct
+		{name: "other status", filter: Filter{Status: &notFound}, want: false},                                                   //nolint:exhaustruct
+		{name: "other request id", filter: Filter{RequestID: "req-2"}, want: false},                                              //nolint:exhaustruct
+		{name: "request number", filter: Filter{Request: map[string]string{"body.id": "42"}}, want: true},                        //nolint:exhaustruct
+		{name: "request string", filter: Filter{Request: map[string]string{"body.name": "John"}}, want: true},                    //nolint:exhaustruct
+		{name: "request bool", filter: Filter{Request: map[string]string{"body.admin": "false"}}, want: true},                    //nolint:exhaustruct
+		{name: "request null", filter: Filter{Request: map[string]string{"body.note": "null"}}, want: true},                      //nolint:exhaustruct
+		{name: "request list item", filter: Filter{Request: map[string]string{"body.emails.0": "john@example.com"}}, want: true}, //nolint:exhaustruct,lll
+		{name: "request other value", filter: Filter{Request: map[string]string{"body.id": "1"}}, want: false},                   //nolint:exhaustruct
+		{name: "request missing key", filter: Filter{Request: map[string]string{"body.age": "42"}}, want: false},                 //nolint:exhaustruct
+		{name: "request list out of range", filter: Filter{Request: map[string]string{"body.emails.1": ""}}, want: false},        //nolint:exhaustruct
+		{name: "request list bad index", filter: Filter{Request: map[string]string{"body.emails.x": ""}}, want: false},           //nolint:exhaustruct
+		{name: "request path through scalar", filter: Filter{Request: map[string]string{"body.id.x": "42"}}, want: false},        //nolint:exhaustruct
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.filter.Match(entry); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalFind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		capacity int
+		paths    []string
+		filter   Filter
+		want     []string
+	}{
+		{name: "all entries in order", capacity: 3, paths: []string{"/a", "/b"}, filter: Filter{}, want: []string{"/a", "/b"}},             //nolint:exhaustruct,lll
+		{name: "oldest entries dropped", capacity: 2, paths: []string{"/a", "/b", "/c"}, filter: Filter{}, want: []string{"/b", "/c"}},     //nolint:exhaustruct,lll
+		{name: "filtered entries", capacity: 3, paths: []string{"/a", "/b", "/a"}, filter: Filter{Path: "/a"}, want: []string{"/a", "/a"}}, //nolint:exhaustruct,lll
+		{name: "no entries", capacity: 3, paths: nil, filter: Filter{}, want: nil},                                                         //nolint:exhaustruct
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			jrn := New(tt.capacity)
+			for _, path := range tt.paths {
+				jrn.Record(Entry{Path: path}) //nolint:exhaustruct
+			}
+
+			got := jrn.Find(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Find() returned %d entries, want %d", len(got), len(tt.want))
+			}
+
+			for i, entry := range got {
+				if entry.Path != tt.want[i] {
+					t.Errorf("Find()[%d].Path = %q, want %q", i, entry.Path, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package scenario
+
+import "sync"
+
+// StateStarted is the initial state of every scenario.
+const StateStarted = "Started"
+
+// Scenarios keep the current states of the named scenarios shared across all the mocks.
+type Scenarios struct {
+	mu     sync.RWMutex
+	states map[string]string
+}
+
+func New() *Scenarios {
+	return &Scenarios{
+		mu:     sync.RWMutex{},
+		states: make(map[string]string),
+	}
+}
+
+// State returns the current state of a scenario.
+func (s *Scenarios) State(name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if state, ok := s.states[name]; ok {
+		return state
+	}
+
+	return StateStarted
+}
+
+// Transition moves a scenario to the state.
+func (s *Scenarios) Transition(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[name] = state
+}
+
+// States returns the current states of all the touched scenarios by their names.
+func (s *Scenarios) States() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]string, len(s.states))
+	for name, state := range s.states {
+		result[name] = state
+	}
+
+	return result
+}
+
+// Reset moves a scenario back to the initial state.
+func (s *Scenarios) Reset(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, name)
+}
+
+// ResetAll moves all the scenarios back to the initial state.
+func (s *Scenarios) ResetAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.states)
+}
+
+// Scenario returns a scenario for mock scripts, e.g. scenario("checkout").transition("paid").
+func (s *Scenarios) Scenario(name string) *Scenario {
+	return &Scenario{
+		Name:      name,
+		State:     s.State(name),
+		scenarios: s,
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// Scenario exposes a named scenario to mock scripts.
+type Scenario struct {
+	Name  string `json:"name"`
+	State string `json:"state"` // State at the moment of the scenario lookup or the last transition.
+
+	scenarios *Scenarios
+}
+
+// Transition moves the scenario to the state.
+func (s *Scenario) Transition(state string) {
+	s.scenarios.Transition(s.Name, state)
+	s.State = state
+}
+
+// Reset moves the scenario back to the initial state.
+func (s *Scenario) Reset() {
+	s.scenarios.Reset(s.Name)
+	s.State = StateStarted
+}
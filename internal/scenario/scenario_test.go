@@ -0,0 +1,157 @@
+package scenario
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+)
+
+func TestScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		act        func(s *Scenarios)
+		wantState  string // State of the checkout scenario.
+		wantStates map[string]string
+	}{
+		{
+			name:       "initial state",
+			act:        func(*Scenarios) {},
+			wantState:  StateStarted,
+			wantStates: map[string]string{},
+		},
+		{
+			name:       "transition",
+			act:        func(s *Scenarios) { s.Transition("checkout", "Paid") },
+			wantState:  "Paid",
+			wantStates: map[string]string{"checkout": "Paid"},
+		},
+		{
+			name: "repeated transition",
+			act: func(s *Scenarios) {
+				s.Transition("checkout", "Paid")
+				s.Transition("checkout", "Shipped")
+			},
+			wantState:  "Shipped",
+			wantStates: map[string]string{"checkout": "Shipped"},
+		},
+		{
+			name: "other scenario",
+			act: func(s *Scenarios) {
+				s.Transition("login", "LoggedIn")
+			},
+			wantState:  StateStarted,
+			wantStates: map[string]string{"login": "LoggedIn"},
+		},
+		{
+			name: "reset",
+			act: func(s *Scenarios) {
+				s.Transition("checkout", "Paid")
+				s.Transition("login", "LoggedIn")
+				s.Reset("checkout")
+			},
+			wantState:  StateStarted,
+			wantStates: map[string]string{"login": "LoggedIn"},
+		},
+		{
+			name: "reset all",
+			act: func(s *Scenarios) {
+				s.Transition("checkout", "Paid")
+				s.Transition("login", "LoggedIn")
+				s.ResetAll()
+			},
+			wantState:  StateStarted,
+			wantStates: map[string]string{},
+		},
+		{
+			name: "script scenario",
+			act: func(s *Scenarios) {
+				checkout := s.Scenario("checkout")
+				checkout.Transition("Paid")
+				s.Scenario("login").Reset()
+			},
+			wantState:  "Paid",
+			wantStates: map[string]string{"checkout": "Paid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			scenarios := New()
+			tt.act(scenarios)
+
+			if got := scenarios.State("checkout"); got != tt.wantState {
+				t.Errorf("State() = %q, want %q", got, tt.wantState)
+			}
+
+			if got := scenarios.States(); !maps.Equal(got, tt.wantStates) {
+				t.Errorf("States() = %v, want %v", got, tt.wantStates)
+			}
+		})
+	}
+}
+
+func TestScenarioState(t *testing.T) {
+	t.Parallel()
+
+	scenarios := New()
+
+	checkout := scenarios.Scenario("checkout")
+	if checkout.State != StateStarted {
+		t.Fatalf("Scenario() state = %q, want %q", checkout.State, StateStarted)
+	}
+
+	checkout.Transition("Paid")
+
+	if checkout.State != "Paid" || scenarios.State("checkout") != "Paid" {
+		t.Errorf("Transition() state = %q, shared state = %q, want %q",
+			checkout.State, scenarios.State("checkout"), "Paid")
+	}
+
+	checkout.Reset()
+
+	if checkout.State != StateStarted || scenarios.State("checkout") != StateStarted {
+		t.Errorf("Reset() state = %q, shared state = %q, want %q",
+			checkout.State, scenarios.State("checkout"), StateStarted)
+	}
+}
+
+func TestScenariosConcurrentAccess(t *testing.T) {
+	t.Parallel()
+
+	const workers = 16
+
+	scenarios := New()
+
+	var wg sync.WaitGroup
+
+	for i := range workers {
+		wg.Go(func() {
+			name := fmt.Sprintf("scenario-%d", i)
+
+			for j := range 100 {
+				scenarios.Transition(name, fmt.Sprintf("step-%d", j))
+				scenarios.Scenario("shared").Transition(name)
+				_ = scenarios.States()
+
+				if j%10 == 0 {
+					scenarios.Reset("shared")
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	states := scenarios.States()
+	for i := range workers {
+		name := fmt.Sprintf("scenario-%d", i)
+		if states[name] != "step-99" {
+			t.Errorf("States() %s = %q, want %q", name, states[name], "step-99")
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package store
+
+// ScriptAPI exposes the store operations available to mock scripts.
+type ScriptAPI struct {
+	store *Store
+}
+
+func (s *Store) ScriptAPI() ScriptAPI {
+	return ScriptAPI{
+		store: s,
+	}
+}
+
+func (a ScriptAPI) Get(key string) any {
+	return a.store.Get(key)
+}
+
+func (a ScriptAPI) Set(key string, value any) error {
+	return a.store.Set(key, value)
+}
+
+func (a ScriptAPI) Delete(key string) bool {
+	return a.store.Delete(key)
+}
+
+func (a ScriptAPI) List(prefix string) []any {
+	return a.store.List(prefix)
+}
+
+func (a ScriptAPI) Increment(key string, delta ...int64) (int64, error) {
+	return a.store.Increment(key, delta...)
+}
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+
+	"github.com/sknv/protomock/pkg/document"
+)
+
+const _snapshotFileMode = 0o644
+
+var errNotInteger = errors.New("value is not an integer")
+
+// Store keeps values shared across all the mocks, the values are stored as plain JSON values,
+// so the mocks never share the same instance of a value.
+type Store struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func New() *Store {
+	return &Store{
+		mu:     sync.RWMutex{},
+		values: make(map[string]any),
+	}
+}
+
+// Get returns a value by the key or nil if the key is missing.
+func (s *Store) Get(key string) any {
+	value, _ := s.Lookup(key)
+
+	return value
+}
+
+// Lookup returns a value by the key and reports whether the key is present.
+func (s *Store) Lookup(key string) (any, bool) {
+	s.mu.RLock()
+	value, ok := s.values[key]
+	s.mu.RUnlock()
+
+	return clone(value), ok
+}
+
+// Set stores a value by the key.
+func (s *Store) Set(key string, value any) error {
+	value, err := document.Normalize(value)
+	if err != nil {
+		return fmt.Errorf("normalize value of %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+
+	return nil
+}
+
+// Delete removes a value by the key and reports whether the key was present.
+func (s *Store) Delete(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.values[key]
+	delete(s.values, key)
+
+	return ok
+}
+
+// List returns the values of the keys starting with the prefix ordered by the keys.
+func (s *Store) List(prefix string) []any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := s.keys(prefix)
+
+	result := make([]any, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, clone(s.values[key]))
+	}
+
+	return result
+}
+
+// Increment adds the delta (1 by default) to an integer value by the key, a missing value is treated as 0.
+// A fractional value is rejected rather than truncated.
+func (s *Store) Increment(key string, delta ...int64) (int64, error) {
+	var inc int64 = 1
+	if len(delta) > 0 {
+		inc = delta[0]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current float64
+
+	if value, ok := s.values[key]; ok && value != nil {
+		number, isNumber := value.(float64)
+		if !isNumber || number != math.Trunc(number) {
+			return 0, fmt.Errorf("value of %s: %w", key, errNotInteger)
+		}
+
+		current = number
+	}
+
+	result := int64(current) + inc
+	s.values[key] = float64(result)
+
+	return result, nil
+}
+
+// Entries returns a copy of all the values by their keys.
+func (s *Store) Entries() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]any, len(s.values))
+	for key, value := range s.values {
+		result[key] = clone(value)
+	}
+
+	return result
+}
+
+// Seed sets all the provided values, keeping the other ones.
+func (s *Store) Seed(values map[string]any) error {
+	for key, value := range values {
+		if err := s.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reset removes all the values.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.values)
+}
+
+// Load seeds the store from a snapshot file, a missing file is ignored.
+func (s *Store) Load(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read snapshot file: %w", err)
+	}
+
+	var values map[string]any
+	if err = json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	return s.Seed(values)
+}
+
+// Save writes all the values to a snapshot file.
+func (s *Store) Save(filePath string) error {
+	data, err := json.MarshalIndent(s.Entries(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if err = os.WriteFile(filePath, data, _snapshotFileMode); err != nil {
+		return fmt.Errorf("write snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// keys returns the sorted keys starting with the prefix, the caller must hold the lock.
+func (s *Store) keys(prefix string) []string {
+	keys := make([]string, 0, len(s.values))
+
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// ----------------------------------------------------------------------------
+
+// clone deep copies a normalized value.
+func clone(value any) any {
+	switch val := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for key, item := range val {
+			result[key] = clone(item)
+		}
+
+		return result
+	case []any:
+		result := make([]any, 0, len(val))
+		for _, item := range val {
+			result = append(result, clone(item))
+		}
+
+		return result
+	default:
+		return value
+	}
+}
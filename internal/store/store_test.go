@@ -0,0 +1,156 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStoreIncrement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		initial map[string]any
+		delta   []int64
+		want    int64
+		wantErr error
+	}{
+		{name: "missing value", initial: nil, delta: nil, want: 1, wantErr: nil},
+		{name: "null value", initial: map[string]any{"key": nil}, delta: nil, want: 1, wantErr: nil},
+		{name: "default delta", initial: map[string]any{"key": 41}, delta: nil, want: 42, wantErr: nil},
+		{name: "custom delta", initial: map[string]any{"key": 40}, delta: []int64{2}, want: 42, wantErr: nil},
+		{name: "negative delta", initial: map[string]any{"key": 43}, delta: []int64{-1}, want: 42, wantErr: nil},
+		{name: "fractional value", initial: map[string]any{"key": 1.5}, delta: nil, want: 0, wantErr: errNotInteger},
+		{name: "string value", initial: map[string]any{"key": "1"}, delta: nil, want: 0, wantErr: errNotInteger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := New()
+			if err := store.Seed(tt.initial); err != nil {
+				t.Fatalf("Seed() error = %v", err)
+			}
+
+			got, err := store.Increment("key", tt.delta...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Increment() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Increment() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreOps(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name string
+		run  func(store *Store) any
+		want any
+	}{
+		{
+			name: "get missing",
+			run:  func(store *Store) any { return store.Get("users:1") },
+			want: nil,
+		},
+		{
+			name: "set normalizes",
+			run: func(store *Store) any {
+				_ = store.Set("users:1", user{ID: 1, Name: "John"})
+
+				return store.Get("users:1")
+			},
+			want: map[string]any{"id": float64(1), "name": "John"},
+		},
+		{
+			name: "get returns copies",
+			run: func(store *Store) any {
+				_ = store.Set("users:1", map[string]any{"tags": []any{"a"}})
+
+				value, _ := store.Get("users:1").(map[string]any)
+				value["tags"] = append(value["tags"].([]any), "b") //nolint:forcetypeassert // known type
+
+				return store.Get("users:1")
+			},
+			want: map[string]any{"tags": []any{"a"}},
+		},
+		{
+			name: "delete",
+			run: func(store *Store) any {
+				_ = store.Set("users:1", 1)
+
+				return []any{store.Delete("users:1"), store.Delete("users:1"), store.Get("users:1")}
+			},
+			want: []any{true, false, nil},
+		},
+		{
+			name: "list by prefix in key order",
+			run: func(store *Store) any {
+				_ = store.Seed(map[string]any{"users:2": "b", "users:1": "a", "orders:1": "c"})
+
+				return store.List("users:")
+			},
+			want: []any{"a", "b"},
+		},
+		{
+			name: "reset",
+			run: func(store *Store) any {
+				_ = store.Set("users:1", 1)
+				store.Reset()
+
+				return store.Entries()
+			},
+			want: map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.run(New()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreSnapshot(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "store.json")
+
+	store := New()
+	if err := store.Load(filePath); err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+
+	if err := store.Seed(map[string]any{"users:1": map[string]any{"id": 1}, "counter": 2}); err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+
+	if err := store.Save(filePath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := New()
+	if err := loaded.Load(filePath); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got, want := loaded.Entries(), store.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %#v, want %#v", got, want)
+	}
+}
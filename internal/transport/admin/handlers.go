@@ -0,0 +1,264 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/uptrace/bunrouter"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/internal/scenario"
+	"github.com/sknv/protomock/internal/store"
+	transportGRPC "github.com/sknv/protomock/internal/transport/grpc"
+	transportHTTP "github.com/sknv/protomock/internal/transport/http"
+	"github.com/sknv/protomock/pkg/http/render"
+	"github.com/sknv/protomock/pkg/option"
+)
+
+type Handlers struct {
+	journal      *journal.Journal
+	store        *store.Store
+	scenarios    *scenario.Scenarios
+	httpHandlers option.Option[*transportHTTP.Handlers]
+	grpcHandlers option.Option[*transportGRPC.Handlers]
+}
+
+func NewHandlers(
+	jrnl *journal.Journal,
+	mocksStore *store.Store,
+	scenarios *scenario.Scenarios,
+	httpHandlers option.Option[*transportHTTP.Handlers],
+	grpcHandlers option.Option[*transportGRPC.Handlers],
+) *Handlers {
+	return &Handlers{
+		journal:      jrnl,
+		store:        mocksStore,
+		scenarios:    scenarios,
+		httpHandlers: httpHandlers,
+		grpcHandlers: grpcHandlers,
+	}
+}
+
+func (h *Handlers) Route(router *bunrouter.Router) {
+	router.GET("/mocks/http", h.listHTTPMocks)
+	router.GET("/mocks/grpc", h.listGRPCServices)
+
+	router.GET("/journal", h.findJournalEntries)
+	router.GET("/journal/count", h.countJournalEntries)
+	router.DELETE("/journal", h.resetJournal)
+
+	router.GET("/store", h.listStoreValues)
+	router.POST("/store", h.seedStore)
+	router.DELETE("/store", h.resetStore)
+	router.GET("/store/*key", h.getStoreValue)
+	router.PUT("/store/*key", h.setStoreValue)
+	router.DELETE("/store/*key", h.deleteStoreValue)
+
+	router.GET("/scenarios", h.listScenarios)
+	router.DELETE("/scenarios", h.resetScenarios)
+	router.GET("/scenarios/:name", h.getScenario)
+	router.PUT("/scenarios/:name", h.setScenarioState)
+	router.DELETE("/scenarios/:name", h.resetScenario)
+}
+
+// listHTTPMocks lists the registered HTTP routes.
+func (h *Handlers) listHTTPMocks(w http.ResponseWriter, _ bunrouter.Request) error {
+	mocks := []HTTPMock{}
+	if h.httpHandlers.IsSome() {
+		mocks = NewHTTPMocksFrom(h.httpHandlers.Unwrap().Mocks())
+	}
+
+	return render.JSON(w, http.StatusOK, mocks) //nolint:wrapcheck // proxy
+}
+
+// listGRPCServices lists the loaded gRPC services along with their methods whether they are mocked or not.
+func (h *Handlers) listGRPCServices(w http.ResponseWriter, _ bunrouter.Request) error {
+	services := []GRPCService{}
+	if h.grpcHandlers.IsSome() {
+		services = NewGRPCServicesFrom(h.grpcHandlers.Unwrap().Packages())
+	}
+
+	return render.JSON(w, http.StatusOK, services) //nolint:wrapcheck // proxy
+}
+
+// findJournalEntries lists the journaled calls matching the query filter.
+func (h *Handlers) findJournalEntries(w http.ResponseWriter, req bunrouter.Request) error {
+	filter, err := parseJournalFilter(req)
+	if err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	return render.JSON(w, http.StatusOK, h.journal.Find(filter)) //nolint:wrapcheck // proxy
+}
+
+// countJournalEntries counts the journaled calls matching the query filter.
+func (h *Handlers) countJournalEntries(w http.ResponseWriter, req bunrouter.Request) error {
+	filter, err := parseJournalFilter(req)
+	if err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	return render.JSON(w, http.StatusOK, CountResponse{Count: h.journal.Count(filter)}) //nolint:wrapcheck // proxy
+}
+
+// resetJournal removes all the journaled calls.
+func (h *Handlers) resetJournal(w http.ResponseWriter, _ bunrouter.Request) error {
+	h.journal.Reset()
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// listStoreValues lists all the stored values by their keys.
+func (h *Handlers) listStoreValues(w http.ResponseWriter, _ bunrouter.Request) error {
+	return render.JSON(w, http.StatusOK, h.store.Entries()) //nolint:wrapcheck // proxy
+}
+
+// seedStore sets all the values by their keys from the request body keeping the other ones.
+func (h *Handlers) seedStore(w http.ResponseWriter, req bunrouter.Request) error {
+	var values map[string]any
+	if err := render.DecodeJSON(req.Body, &values); err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	if err := h.store.Seed(values); err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// resetStore removes all the stored values.
+func (h *Handlers) resetStore(w http.ResponseWriter, _ bunrouter.Request) error {
+	h.store.Reset()
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// getStoreValue returns a stored value by the key.
+func (h *Handlers) getStoreValue(w http.ResponseWriter, req bunrouter.Request) error {
+	value, ok := h.store.Lookup(req.Param("key"))
+	if !ok {
+		return render.JSON(w, http.StatusNotFound, ErrorResponse{Error: "key not found"}) //nolint:wrapcheck // proxy
+	}
+
+	return render.JSON(w, http.StatusOK, value) //nolint:wrapcheck // proxy
+}
+
+// setStoreValue stores the request body by the key.
+func (h *Handlers) setStoreValue(w http.ResponseWriter, req bunrouter.Request) error {
+	var value any
+	if err := render.DecodeJSON(req.Body, &value); err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	if err := h.store.Set(req.Param("key"), value); err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// deleteStoreValue removes a stored value by the key.
+func (h *Handlers) deleteStoreValue(w http.ResponseWriter, req bunrouter.Request) error {
+	if !h.store.Delete(req.Param("key")) {
+		return render.JSON(w, http.StatusNotFound, ErrorResponse{Error: "key not found"}) //nolint:wrapcheck // proxy
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// listScenarios lists the current states of the scenarios by their names.
+func (h *Handlers) listScenarios(w http.ResponseWriter, _ bunrouter.Request) error {
+	return render.JSON(w, http.StatusOK, h.scenarios.States()) //nolint:wrapcheck // proxy
+}
+
+// resetScenarios moves all the scenarios back to the initial state.
+func (h *Handlers) resetScenarios(w http.ResponseWriter, _ bunrouter.Request) error {
+	h.scenarios.ResetAll()
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// getScenario returns the current state of a scenario.
+func (h *Handlers) getScenario(w http.ResponseWriter, req bunrouter.Request) error {
+	return render.JSON(w, http.StatusOK, ScenarioState{ //nolint:wrapcheck // proxy
+		State: h.scenarios.State(req.Param("name")),
+	})
+}
+
+// setScenarioState forces a scenario to the state from the request body.
+func (h *Handlers) setScenarioState(w http.ResponseWriter, req bunrouter.Request) error {
+	var body ScenarioState
+	if err := render.DecodeJSON(req.Body, &body); err != nil {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()}) //nolint:wrapcheck // proxy
+	}
+
+	if body.State == "" {
+		return render.JSON(w, http.StatusBadRequest, ErrorResponse{Error: "state is required"}) //nolint:wrapcheck // proxy
+	}
+
+	h.scenarios.Transition(req.Param("name"), body.State)
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// resetScenario moves a scenario back to the initial state.
+func (h *Handlers) resetScenario(w http.ResponseWriter, req bunrouter.Request) error {
+	h.scenarios.Reset(req.Param("name"))
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+
+// _journalRequestPrefix prefixes the query params matching the request fields, e.g. request.body.id=42.
+const _journalRequestPrefix = "request."
+
+func parseJournalFilter(req bunrouter.Request) (journal.Filter, error) {
+	query := req.URL.Query()
+
+	filter := journal.Filter{
+		Protocol:  query.Get("protocol"),
+		Method:    query.Get("method"),
+		Route:     query.Get("route"),
+		Path:      query.Get("path"),
+		Status:    nil,
+		RequestID: query.Get("requestId"),
+		Request:   make(map[string]string),
+	}
+
+	if value := query.Get("status"); value != "" {
+		code, err := strconv.Atoi(value)
+		if err != nil {
+			return journal.Filter{}, fmt.Errorf("invalid status %q: %w", value, err)
+		}
+
+		filter.Status = &code
+	}
+
+	for key := range query {
+		if path, ok := strings.CutPrefix(key, _journalRequestPrefix); ok {
+			filter.Request[path] = query.Get(key)
+		}
+	}
+
+	return filter, nil
+}
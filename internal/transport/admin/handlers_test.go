@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/uptrace/bunrouter"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/internal/scenario"
+	"github.com/sknv/protomock/internal/store"
+	transportGRPC "github.com/sknv/protomock/internal/transport/grpc"
+	transportHTTP "github.com/sknv/protomock/internal/transport/http"
+	"github.com/sknv/protomock/pkg/http/middleware"
+	"github.com/sknv/protomock/pkg/http/render"
+	"github.com/sknv/protomock/pkg/option"
+)
+
+func newTestRouter(jrnl *journal.Journal, mocksStore *store.Store) *bunrouter.Router {
+	handlers := NewHandlers(
+		jrnl, mocksStore, scenario.New(), option.None[*transportHTTP.Handlers](), option.None[*transportGRPC.Handlers](),
+	)
+
+	router := bunrouter.New(bunrouter.Use(middleware.HandleError))
+	handlers.Route(router)
+
+	return router
+}
+
+func serve(router *bunrouter.Router, method, target, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	return recorder
+}
+
+func TestFindJournalEntries(t *testing.T) {
+	t.Parallel()
+
+	jrnl := journal.New(10)
+	jrnl.Record(journal.Entry{ //nolint:exhaustruct
+		Protocol: journal.ProtocolHTTP, Method: "POST", Route: "/users", Path: "/users", Status: 201, RequestID: "req-1",
+		Request: map[string]any{"body": map[string]any{"name": "John"}},
+	})
+	jrnl.Record(journal.Entry{ //nolint:exhaustruct
+		Protocol: journal.ProtocolHTTP, Method: "GET", Route: "/users/:user_id", Path: "/users/42", Status: 404,
+		RequestID: "req-2",
+		Request:   map[string]any{"params": map[string]any{"user_id": "42"}},
+	})
+	jrnl.Record(journal.Entry{ //nolint:exhaustruct
+		Protocol: journal.ProtocolGRPC, Method: "/test.TestService/Unary", Route: "/test.TestService/Unary",
+		Path: "/test.TestService/Unary", Status: 0, RequestID: "req-3",
+		Request: map[string]any{"body": map[string]any{"name": "John"}},
+	})
+
+	router := newTestRouter(jrnl, store.New())
+
+	tests := []struct {
+		name           string
+		query          string
+		wantStatus     int
+		wantRequestIDs []string
+	}{
+		{name: "no filter", query: "", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-1", "req-2", "req-3"}},
+		{name: "protocol", query: "protocol=grpc", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-3"}},
+		{name: "method and route", query: "method=GET&route=/users/:user_id", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-2"}}, //nolint:lll
+		{name: "path", query: "path=/users", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-1"}},
+		{name: "status", query: "status=0", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-3"}},
+		{name: "request id", query: "requestId=req-2", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-2"}},
+		{name: "request field", query: "request.body.name=John", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-1", "req-3"}},                   //nolint:lll
+		{name: "request field and protocol", query: "request.body.name=John&protocol=http", wantStatus: http.StatusOK, wantRequestIDs: []string{"req-1"}}, //nolint:lll
+		{name: "no match", query: "request.params.user_id=1", wantStatus: http.StatusOK, wantRequestIDs: []string{}},
+		{name: "invalid status", query: "status=ok", wantStatus: http.StatusBadRequest, wantRequestIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := serve(router, http.MethodGet, "/journal?"+tt.query, "")
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("GET /journal status = %d, want %d, body = %q", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+
+			counter := serve(router, http.MethodGet, "/journal/count?"+tt.query, "")
+			if counter.Code != tt.wantStatus {
+				t.Fatalf("GET /journal/count status = %d, want %d", counter.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var entries []journal.Entry
+			if err := render.DecodeJSON(recorder.Body, &entries); err != nil {
+				t.Fatalf("decode entries error = %v", err)
+			}
+
+			requestIDs := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				requestIDs = append(requestIDs, entry.RequestID)
+			}
+
+			if !slices.Equal(requestIDs, tt.wantRequestIDs) {
+				t.Errorf("GET /journal request ids = %q, want %q", requestIDs, tt.wantRequestIDs)
+			}
+
+			var count CountResponse
+			if err := render.DecodeJSON(counter.Body, &count); err != nil {
+				t.Fatalf("decode count error = %v", err)
+			}
+
+			if count.Count != len(tt.wantRequestIDs) {
+				t.Errorf("GET /journal/count = %d, want %d", count.Count, len(tt.wantRequestIDs))
+			}
+		})
+	}
+}
+
+func TestResetJournal(t *testing.T) {
+	t.Parallel()
+
+	jrnl := journal.New(10)
+	jrnl.Record(journal.Entry{Protocol: journal.ProtocolHTTP}) //nolint:exhaustruct
+
+	router := newTestRouter(jrnl, store.New())
+
+	if recorder := serve(router, http.MethodDelete, "/journal", ""); recorder.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /journal status = %d, want %d", recorder.Code, http.StatusNoContent)
+	}
+
+	if count := jrnl.Count(journal.Filter{}); count != 0 { //nolint:exhaustruct
+		t.Errorf("journal count = %d, want 0", count)
+	}
+}
+
+func TestStoreRoutes(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter(journal.New(10), store.New())
+
+	// The steps share the store and run in order.
+	steps := []struct {
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string // Compared if not empty.
+	}{
+		{method: http.MethodGet, target: "/store", body: "", wantStatus: http.StatusOK, wantBody: `{}`},
+		{method: http.MethodPut, target: "/store/users/1", body: `{"name": "John"}`, wantStatus: http.StatusNoContent, wantBody: ""},
+		{method: http.MethodGet, target: "/store/users/1", body: "", wantStatus: http.StatusOK, wantBody: `{"name":"John"}`},
+		{method: http.MethodPut, target: "/store/users/1", body: `{"name":`, wantStatus: http.StatusBadRequest, wantBody: ""},
+		{method: http.MethodPost, target: "/store", body: `{"users/2": {"name": "Jane"}, "counter": 1}`, wantStatus: http.StatusNoContent, wantBody: ""}, //nolint:lll
+		{method: http.MethodPost, target: "/store", body: `[1]`, wantStatus: http.StatusBadRequest, wantBody: ""},
+		{method: http.MethodGet, target: "/store", body: "", wantStatus: http.StatusOK, wantBody: `{"counter":1,"users/1":{"name":"John"},"users/2":{"name":"Jane"}}`}, //nolint:lll
+		{method: http.MethodDelete, target: "/store/users/1", body: "", wantStatus: http.StatusNoContent, wantBody: ""},
+		{method: http.MethodDelete, target: "/store/users/1", body: "", wantStatus: http.StatusNotFound, wantBody: ""},
+		{method: http.MethodGet, target: "/store/users/1", body: "", wantStatus: http.StatusNotFound, wantBody: ""},
+		{method: http.MethodDelete, target: "/store", body: "", wantStatus: http.StatusNoContent, wantBody: ""},
+		{method: http.MethodGet, target: "/store", body: "", wantStatus: http.StatusOK, wantBody: `{}`},
+	}
+
+	for _, step := range steps {
+		recorder := serve(router, step.method, step.target, step.body)
+		if recorder.Code != step.wantStatus {
+			t.Fatalf("%s %s status = %d, want %d, body = %q",
+				step.method, step.target, recorder.Code, step.wantStatus, recorder.Body)
+		}
+
+		if step.wantBody != "" && strings.TrimSpace(recorder.Body.String()) != step.wantBody {
+			t.Errorf("%s %s body = %q, want %q", step.method, step.target, recorder.Body, step.wantBody)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	transportGRPC "github.com/sknv/protomock/internal/transport/grpc"
+	transportHTTP "github.com/sknv/protomock/internal/transport/http"
+)
+
+type HTTPMock struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	File   string `json:"file"`
+}
+
+func NewHTTPMocksFrom(mocks transportHTTP.Mocks) []HTTPMock {
+	result := make([]HTTPMock, 0, len(mocks))
+
+	for _, mock := range mocks {
+		result = append(result, HTTPMock{
+			Method: mock.Method,
+			Path:   mock.Path,
+			File:   mock.File,
+		})
+	}
+
+	return result
+}
+
+type GRPCMethod struct {
+	Name            string `json:"name"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+	Mocked          bool   `json:"mocked"`
+	File            string `json:"file,omitempty"` // Script or static response file path if mocked.
+}
+
+type GRPCService struct {
+	Name      string       `json:"name"`
+	ProtoFile string       `json:"protoFile"`
+	Methods   []GRPCMethod `json:"methods"`
+}
+
+func NewGRPCServicesFrom(packages transportGRPC.Packages) []GRPCService {
+	result := make([]GRPCService, 0, len(packages))
+
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, service := range file.Services {
+				result = append(result, newGRPCService(service, file.ProtoFile.Path()))
+			}
+		}
+	}
+
+	return result
+}
+
+func newGRPCService(service transportGRPC.Service, protoFile string) GRPCService {
+	// Index the mocks by method name.
+	mocks := make(map[string]transportGRPC.Mock, len(service.Mocks))
+	for _, mock := range service.Mocks {
+		mocks[string(mock.ProtoMethod.Name())] = mock
+	}
+
+	protoMethods := service.ProtoService.Methods()
+	methods := make([]GRPCMethod, 0, protoMethods.Len())
+
+	for i := range protoMethods.Len() {
+		protoMethod := protoMethods.Get(i)
+		name := string(protoMethod.Name())
+		mock, mocked := mocks[name]
+
+		methods = append(methods, GRPCMethod{
+			Name:            name,
+			ClientStreaming: protoMethod.IsStreamingClient(),
+			ServerStreaming: protoMethod.IsStreamingServer(),
+			Mocked:          mocked,
+			File:            mock.File,
+		})
+	}
+
+	return GRPCService{
+		Name:      string(service.ProtoService.FullName()),
+		ProtoFile: protoFile,
+		Methods:   methods,
+	}
+}
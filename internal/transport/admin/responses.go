@@ -0,0 +1,13 @@
+package admin
+
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type ScenarioState struct {
+	State string `json:"state"`
+}
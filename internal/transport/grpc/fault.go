@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/grpc/codec"
+	"github.com/sknv/protomock/pkg/net/conntrack"
+)
+
+var (
+	errUnsupportedFault = errors.New("unsupported fault")
+	errUnknownConn      = errors.New("unknown connection")
+)
+
+// fault returns the fault provided by the script or the default one of the method.
+func (h *Handlers) fault(method protoreflect.MethodDescriptor, response MockResponse) (fault.Fault, error) {
+	if response.Fault != nil {
+		return parseFault(response.Fault)
+	}
+
+	return h.faults[fullMethodName(method)], nil
+}
+
+// parseFault parses a fault provided by a script or a static response.
+func parseFault(value any) (fault.Fault, error) {
+	flt, err := fault.Parse(value)
+	if err != nil {
+		return fault.Fault{}, fmt.Errorf("parse fault: %w", err)
+	}
+
+	return flt, nil
+}
+
+// breakCall produces the faults which break the call rather than its messages. The connection and stream faults
+// fail the call right away, a slow drip throttles the connection of the call until the returned release is called.
+func (h *Handlers) breakCall(ctx context.Context, flt fault.Fault) (func(), error) {
+	switch flt.Type {
+	case fault.TypeConnectionReset, fault.TypeEmptyReply:
+		conn, err := h.conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = flt.Break(conn); err != nil {
+			return nil, fmt.Errorf("break connection: %w", err)
+		}
+
+		return nil, status.Errorf(codes.Unavailable, "connection broken by %s fault", flt.Type) //nolint:wrapcheck,lll // plain gRPC error
+	case fault.TypeStreamReset:
+		// A handler can't send RST_STREAM itself, so end the call the way the client reports a reset stream,
+		// the connection is shared by other calls and is kept open.
+		return nil, status.Error(codes.Internal, "stream terminated by RST_STREAM with error code: INTERNAL_ERROR") //nolint:wrapcheck,lll // plain gRPC error
+	case fault.TypeSlowDrip:
+		conn, err := h.conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The messages are framed whole, so the connection writes are split into chunks instead.
+		return conn.Throttle(flt.ChunkSize, flt.PauseDuration()), nil
+	default:
+		return func() {}, nil
+	}
+}
+
+// conn returns the tracked connection of the call.
+func (h *Handlers) conn(ctx context.Context) (*conntrack.Conn, error) {
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, errUnknownConn
+	}
+
+	conn := h.conns.Lookup(client.Addr)
+	if conn.IsNone() {
+		return nil, fmt.Errorf("%w: %s", errUnknownConn, client.Addr)
+	}
+
+	return conn.Unwrap(), nil
+}
+
+// sendMessage sends the message broken according to the body fault if any.
+func sendMessage(stream grpc.ServerStream, flt fault.Fault, message proto.Message) error {
+	if flt.Type != fault.TypeTruncatedBody && flt.Type != fault.TypeMalformedBody {
+		return stream.SendMsg(message) //nolint:wrapcheck // plain gRPC error
+	}
+
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+
+	return stream.SendMsg(&codec.Frame{Data: flt.Corrupt(data)}) //nolint:wrapcheck // plain gRPC error
+}
+
+// validateFaults checks the default faults can break the methods, the bidirectional streams are driven
+// by the scripts message by message, so they don't support faults.
+func validateFaults(faults map[string]fault.Fault, methods map[string]protoreflect.MethodDescriptor) error {
+	for fullMethod, flt := range faults {
+		method, ok := methods[fullMethod]
+		if ok && !flt.IsNone() && method.IsStreamingClient() && method.IsStreamingServer() {
+			return fmt.Errorf("%w for bidirectional streaming method %s: %s", errUnsupportedFault, fullMethod, flt.Type)
+		}
+	}
+
+	return nil
+}
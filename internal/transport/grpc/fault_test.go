@@ -0,0 +1,279 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/log"
+)
+
+func TestHandleUnaryFault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		script   string
+		faults   map[string]fault.Fault
+		wantCode codes.Code
+	}{
+		{name: "no fault", script: `({ body: { message: "Hello, John" } })`, faults: nil, wantCode: codes.OK},
+		{name: "truncated body", script: `({ body: { message: "Hello, John" }, fault: "truncated_body" })`, faults: nil, wantCode: codes.Internal},          //nolint:lll
+		{name: "malformed body", script: `({ body: { message: "Hello, John" }, fault: "malformed_body" })`, faults: nil, wantCode: codes.Internal},          //nolint:lll
+		{name: "connection reset", script: `({ body: { message: "Hello, John" }, fault: "connection_reset" })`, faults: nil, wantCode: codes.Unavailable},   //nolint:lll
+		{name: "empty reply", script: `({ body: { message: "Hello, John" }, fault: "empty_reply" })`, faults: nil, wantCode: codes.Unavailable},             //nolint:lll
+		{name: "stream reset", script: `({ body: { message: "Hello, John" }, fault: "stream_reset" })`, faults: nil, wantCode: codes.Internal},              //nolint:lll
+		{name: "slow drip", script: `({ body: { message: "Hello, John" }, fault: { type: "slow_drip", chunkSize: 64 } })`, faults: nil, wantCode: codes.OK}, //nolint:lll
+		{
+			name:     "default fault",
+			script:   `({ body: { message: "Hello, John" } })`,
+			faults:   map[string]fault.Fault{"/test.TestService/Unary": {Type: fault.TypeMalformedBody}}, //nolint:exhaustruct
+			wantCode: codes.Internal,
+		},
+		{
+			name:     "script overrides default fault",
+			script:   `({ body: { message: "Hello, John" }, fault: "truncated_body" })`,
+			faults:   map[string]fault.Fault{"/test.TestService/Unary": {Type: fault.TypeMalformedBody}}, //nolint:exhaustruct
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := startTestServer(t, map[string]string{"Unary.js": tt.script}, testOptions{faults: tt.faults}) //nolint:exhaustruct
+
+			got, err := server.unary(t.Context(), "John")
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("Unary() error = %v, want code %s", err, tt.wantCode)
+			}
+
+			if err == nil && got != "Hello, John" {
+				t.Errorf("Unary() = %q, want %q", got, "Hello, John")
+			}
+		})
+	}
+}
+
+func TestHandleUnaryFaultNextCall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fault    string
+		wantCode codes.Code
+	}{
+		{name: "connection reset", fault: fault.TypeConnectionReset, wantCode: codes.Unavailable},
+		{name: "empty reply", fault: fault.TypeEmptyReply, wantCode: codes.Unavailable},
+		{name: "stream reset", fault: fault.TypeStreamReset, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := startTestServer(t, map[string]string{"Unary.js": fmt.Sprintf(`(function () {
+  const fault = request.body.name === "John" ? %q : undefined
+
+  return { body: { message: "Hello, " + request.body.name }, fault }
+})()`, tt.fault)}, testOptions{}) //nolint:exhaustruct
+
+			if _, err := server.unary(t.Context(), "John"); status.Code(err) != tt.wantCode {
+				t.Fatalf("Unary() error = %v, want code %s", err, tt.wantCode)
+			}
+
+			// The fault breaks the current call only, the client reconnects if needed.
+			if got, err := server.unary(t.Context(), "Jane"); err != nil || got != "Hello, Jane" {
+				t.Errorf("Unary() next call = %q, %v, want %q", got, err, "Hello, Jane")
+			}
+		})
+	}
+}
+
+func TestHandleUnarySlowDrip(t *testing.T) {
+	t.Parallel()
+
+	// The response takes a few chunks of the headers, the message and the trailers.
+	server := startTestServer(t, map[string]string{"Unary.js": `(function () {
+  const fault = request.body.name === "John" ? { type: "slow_drip", chunkSize: 8, interval: 20 } : undefined
+
+  return { body: { message: "Hello, " + request.body.name }, fault }
+})()`}, testOptions{}) //nolint:exhaustruct
+
+	start := time.Now()
+
+	got, err := server.unary(t.Context(), "John")
+	if err != nil || got != "Hello, John" {
+		t.Fatalf("Unary() = %q, %v, want %q", got, err, "Hello, John")
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Millisecond*100 {
+		t.Errorf("Unary() took %s, want at least %s", elapsed, time.Millisecond*100)
+	}
+
+	// The throttle is lifted once the call is done.
+	start = time.Now()
+
+	if _, err = server.unary(t.Context(), "Jane"); err != nil {
+		t.Fatalf("Unary() next call error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Millisecond*100 {
+		t.Errorf("Unary() next call took %s, want less than %s", elapsed, time.Millisecond*100)
+	}
+}
+
+func TestHandleClientStreamFault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fault    string
+		wantCode codes.Code
+	}{
+		{name: "connection reset", fault: fault.TypeConnectionReset, wantCode: codes.Unavailable},
+		{name: "empty reply", fault: fault.TypeEmptyReply, wantCode: codes.Unavailable},
+		{name: "stream reset", fault: fault.TypeStreamReset, wantCode: codes.Internal},
+		{name: "truncated body", fault: fault.TypeTruncatedBody, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := startTestServer(t, map[string]string{
+				"ClientStream.js": fmt.Sprintf(`({ body: { message: "Hello" }, fault: %q })`, tt.fault),
+			}, testOptions{}) //nolint:exhaustruct
+
+			stream := server.stream(t.Context(), t, "ClientStream")
+			if err := stream.SendMsg(server.request("John")); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+
+			if err := stream.CloseSend(); err != nil {
+				t.Fatalf("CloseSend() error = %v", err)
+			}
+
+			if _, err := server.recv(stream); status.Code(err) != tt.wantCode {
+				t.Errorf("recv() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandleServerStreamFault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fault    string
+		wantCode codes.Code
+	}{
+		{name: "connection reset", fault: fault.TypeConnectionReset, wantCode: codes.Unavailable},
+		{name: "empty reply", fault: fault.TypeEmptyReply, wantCode: codes.Unavailable},
+		{name: "stream reset", fault: fault.TypeStreamReset, wantCode: codes.Internal},
+		{name: "malformed body", fault: fault.TypeMalformedBody, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The messages sent by the script are not affected by the fault, the returned ones are.
+			// The delay lets the sent message out before the connection is dropped.
+			server := startTestServer(t, map[string]string{"ServerStream.js": fmt.Sprintf(`(function () {
+  stream.send({ message: "first" })
+
+  return { messages: [{ body: { message: "second" } }], delay: 50, fault: %q }
+})()`, tt.fault)}, testOptions{}) //nolint:exhaustruct
+
+			stream := server.stream(t.Context(), t, "ServerStream")
+			if err := stream.SendMsg(server.request("John")); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+
+			if err := stream.CloseSend(); err != nil {
+				t.Fatalf("CloseSend() error = %v", err)
+			}
+
+			got, err := server.recv(stream)
+			if err != nil || got != "first" {
+				t.Fatalf("recv() = %q, %v, want %q", got, err, "first")
+			}
+
+			if _, err = server.recv(stream); status.Code(err) != tt.wantCode {
+				t.Errorf("recv() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateFaults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		faults  map[string]fault.Fault
+		wantErr error
+	}{
+		{name: "body fault", faults: map[string]fault.Fault{"/test.TestService/Unary": {Type: fault.TypeTruncatedBody}}, wantErr: nil},                                //nolint:exhaustruct,lll
+		{name: "connection fault", faults: map[string]fault.Fault{"/test.TestService/ClientStream": {Type: fault.TypeConnectionReset}}, wantErr: nil},                 //nolint:exhaustruct,lll
+		{name: "slow drip", faults: map[string]fault.Fault{"/test.TestService/ServerStream": {Type: fault.TypeSlowDrip}}, wantErr: nil},                               //nolint:exhaustruct,lll
+		{name: "unknown method", faults: map[string]fault.Fault{"/test.TestService/Other": {Type: fault.TypeEmptyReply}}, wantErr: nil},                               //nolint:exhaustruct,lll
+		{name: "bidirectional stream", faults: map[string]fault.Fault{"/test.TestService/BidiStream": {Type: fault.TypeMalformedBody}}, wantErr: errUnsupportedFault}, //nolint:exhaustruct,lll
+	}
+
+	server := startTestServer(t, nil, testOptions{}) //nolint:exhaustruct
+	methods := server.handlers.routes.Load().methods
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := validateFaults(tt.faults, methods); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validateFaults() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildPackagesStaticFault(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.ToContext(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name    string
+		fault   string
+		wantErr bool
+	}{
+		{name: "known fault", fault: `"stream_reset"`, wantErr: false},
+		{name: "fault object", fault: `{"type": "slow_drip", "chunkSize": 16}`, wantErr: false},
+		{name: "unknown fault", fault: `"stream_rst"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mocksDir := t.TempDir()
+			writeFile(t, filepath.Join(mocksDir, "test", "service.proto"), _testProto)
+			writeFile(t, filepath.Join(mocksDir, "test", "TestService", "Unary.json"),
+				`{"body": {"message": "Hello"}, "fault": `+tt.fault+`}`)
+
+			if _, err := BuildPackages(ctx, mocksDir, ""); (err != nil) != tt.wantErr {
+				t.Errorf("BuildPackages() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
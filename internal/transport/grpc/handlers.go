@@ -2,104 +2,551 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/grpc/middleware/requestid"
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/log"
+	"github.com/sknv/protomock/pkg/net/conntrack"
+	"github.com/sknv/protomock/pkg/option"
+	"github.com/sknv/protomock/pkg/protobuf/dynamic"
 )
 
 type Handlers struct {
-	packages Packages
+	journal  option.Option[*journal.Journal] // Records the handled calls if set.
+	runtimes *js.Pool                        // Runtimes with the globals shared by every mock, e.g. the store.
+	upstream option.Option[*Upstream]        // Serves the unmocked calls if set.
+	recorder option.Option[*Recorder]        // Records the upstream responses if set.
+	faults   map[string]fault.Fault          // Default faults by full methods, e.g. /example.ExampleService/SayHello.
+	timeouts js.Timeouts                     // Script timeouts by full methods.
+	conns    *conntrack.Tracker              // Connections of the server to break them by the faults.
+	routes   atomic.Pointer[routes]          // Swapped on reload.
 }
 
-func NewHandlers(packages Packages) *Handlers {
-	return &Handlers{
-		packages: packages,
+func NewHandlers(
+	packages Packages,
+	registry *Registry,
+	jrnl option.Option[*journal.Journal],
+	runtimes *js.Pool,
+	upstream option.Option[*Upstream],
+	recorder option.Option[*Recorder],
+	faults map[string]fault.Fault,
+	timeouts js.Timeouts,
+	conns *conntrack.Tracker,
+) (*Handlers, error) {
+	handlers := &Handlers{
+		journal:  jrnl,
+		runtimes: runtimes,
+		upstream: upstream,
+		recorder: recorder,
+		faults:   faults,
+		timeouts: timeouts,
+		conns:    conns,
+		routes:   atomic.Pointer[routes]{},
 	}
+
+	if err := handlers.Reload(packages, registry); err != nil {
+		return nil, err
+	}
+
+	return handlers, nil
 }
 
+// Route registers the reflection service, the mocks are served by Handle.
 func (h *Handlers) Route(server *grpc.Server) {
-	for _, pkg := range h.packages {
-		registerPackage(server, pkg)
+	registerReflection(server, h)
+}
+
+// Reload atomically swaps the current mocks with the provided ones.
+func (h *Handlers) Reload(packages Packages, registry *Registry) error {
+	routes := newRoutes(packages, registry)
+
+	if err := validateStaticMocks(routes.mocks, registry); err != nil {
+		return fmt.Errorf("validate static mocks: %w", err)
 	}
+
+	if err := validateFaults(h.faults, routes.methods); err != nil {
+		return fmt.Errorf("validate faults: %w", err)
+	}
+
+	h.routes.Store(routes)
+
+	return nil
+}
+
+// Packages returns the currently served packages.
+func (h *Handlers) Packages() Packages {
+	return h.routes.Load().packages
 }
 
-func registerPackage(server *grpc.Server, pkg Package) {
-	for _, file := range pkg.Files {
-		registerFile(server, file)
+// Handle serves every mocked method as a stream, so that the methods can be swapped at runtime.
+// It is meant to be used as an unknown service handler of the server.
+//
+//nolint:nonamedreturns // used in defer
+func (h *Handlers) Handle(_ any, stream grpc.ServerStream) (err error) {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+
+	// Record the call to the journal if there is one.
+	var (
+		start = time.Now()
+		call  call
+	)
+
+	if h.journal.IsSome() {
+		defer func() {
+			h.journal.Unwrap().Record(newJournalEntry(stream.Context(), fullMethod, start, err, call))
+		}()
+	}
+
+	current := h.routes.Load()
+
+	mock, ok := current.mocks[fullMethod]
+	if !ok {
+		return h.handleUnmocked(stream, fullMethod, current, &call)
+	}
+
+	method := mock.ProtoMethod
+
+	switch {
+	case method.IsStreamingServer() && !method.IsStreamingClient():
+		return h.handleServerStream(stream, mock, current.registry, &call)
+	case !method.IsStreamingServer() && method.IsStreamingClient():
+		return h.handleClientStream(stream, mock, current.registry, &call)
+	case !method.IsStreamingServer() && !method.IsStreamingClient():
+		return h.handleUnary(stream, mock, current.registry, &call)
+	default:
+		return h.handleBidiStream(stream, mock, current.registry, &call)
 	}
 }
 
-func registerFile(server *grpc.Server, file File) {
-	for _, svc := range file.Services {
-		registerService(server, svc)
+// handleUnmocked passes the call to the upstream if there is one, recording the unary calls of the loaded methods
+// in the record mode. Responds with Unimplemented otherwise.
+func (h *Handlers) handleUnmocked(stream grpc.ServerStream, fullMethod string, current *routes, call *call) error {
+	if h.upstream.IsNone() {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod) //nolint:wrapcheck // plain gRPC error
 	}
+
+	method, ok := current.methods[fullMethod]
+	if !ok || h.recorder.IsNone() || method.IsStreamingClient() || method.IsStreamingServer() {
+		return h.upstream.Unwrap().Proxy(stream, fullMethod)
+	}
+
+	return h.handleRecord(stream, fullMethod, method, current.registry, call)
+}
+
+// handleRecord passes a unary call to the upstream and records its response as a mock.
+func (h *Handlers) handleRecord(
+	stream grpc.ServerStream,
+	fullMethod string,
+	method protoreflect.MethodDescriptor,
+	registry *Registry,
+	call *call,
+) error {
+	ctx := stream.Context()
+
+	req := dynamicpb.NewMessage(method.Input())
+	if err := stream.RecvMsg(req); err != nil {
+		return err //nolint:wrapcheck // plain gRPC error
+	}
+
+	request, err := NewMockRequestFrom(ctx, req)
+	if err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	call.request = request
+
+	upstream := h.upstream.Unwrap().Invoke(ctx, fullMethod, method, req)
+
+	// The upstream response is passed through even if it can't be recorded, e.g. due to an unknown error detail.
+	response, err := NewMockResponseFromUpstream(registry, upstream)
+	if err != nil {
+		log.FromContext(ctx).WarnContext(ctx, "Can't record grpc mock", slog.Any("error", err))
+	} else {
+		call.response = response
+		recordMock(ctx, h.recorder.Unwrap(), method, response)
+	}
+
+	// Pass the upstream response as is.
+	if err = stream.SetHeader(upstream.Headers); err != nil {
+		return err //nolint:wrapcheck // plain gRPC error
+	}
+
+	stream.SetTrailer(upstream.Trailers)
+
+	if upstream.Err != nil {
+		return upstream.Err
+	}
+
+	return stream.SendMsg(upstream.Body) //nolint:wrapcheck // plain gRPC error
 }
 
-func registerService(server *grpc.Server, service Service) {
-	// Register each method in the service.
-	grpcMethods := make([]grpc.MethodDesc, 0, len(service.Mocks))
+// recordMock saves the upstream response of a unary call as the method script,
+// a failure is only logged, so that the call is still proxied.
+func recordMock(ctx context.Context, recorder *Recorder, method protoreflect.MethodDescriptor, response MockResponse) {
+	logger := log.FromContext(ctx)
+
+	file, err := recorder.Record(method, response)
+	if err != nil {
+		logger.WarnContext(ctx, "Can't record grpc mock", slog.Any("error", err))
+
+		return
+	}
+
+	if file != "" {
+		logger.InfoContext(ctx, "Grpc mock recorded", slog.String("file", file))
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+type routes struct {
+	packages Packages
+	registry *Registry
+	mocks    map[string]Mock                          // Mocks by full method name, e.g. /example.ExampleService/SayHello.
+	methods  map[string]protoreflect.MethodDescriptor // All the loaded methods including the ones without mocks.
+	services map[string]grpc.ServiceInfo              // All the loaded services including the ones without mocks.
+}
+
+func newRoutes(packages Packages, registry *Registry) *routes {
+	result := &routes{
+		packages: packages,
+		registry: registry,
+		mocks:    make(map[string]Mock),
+		methods:  make(map[string]protoreflect.MethodDescriptor),
+		services: make(map[string]grpc.ServiceInfo),
+	}
+
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, service := range file.Services {
+				result.addService(service, file.ProtoFile.Path())
+			}
+		}
+	}
+
+	return result
+}
+
+func (r *routes) addService(service Service, fileName string) {
+	serviceName := string(service.ProtoService.FullName())
+	methods := make([]grpc.MethodInfo, 0, len(service.Mocks))
+
+	protoMethods := service.ProtoService.Methods()
+	for i := range protoMethods.Len() {
+		method := protoMethods.Get(i)
+		r.methods[fullMethodName(method)] = method
+	}
 
 	for _, mock := range service.Mocks {
 		method := mock.ProtoMethod
-		methodName := string(method.Name())
-		inputType := method.Input()
-		outputType := method.Output()
-
-		// Create a handler for the method.
-		handler := func(ctx context.Context, req any) (any, error) {
-			// Convert the request to a map[string]any.
-			reqMessage, _ := req.(*dynamicpb.Message)
-
-			request, err := NewMockRequestFrom(ctx, reqMessage)
-			if err != nil {
-				return nil, fmt.Errorf("decode request: %w", err)
-			}
+		r.mocks[fullMethodName(method)] = mock
+
+		methods = append(methods, grpc.MethodInfo{
+			Name:           string(method.Name()),
+			IsClientStream: method.IsStreamingClient(),
+			IsServerStream: method.IsStreamingServer(),
+		})
+	}
+
+	r.services[serviceName] = grpc.ServiceInfo{
+		Methods:  methods,
+		Metadata: fileName,
+	}
+}
+
+// validateStaticMocks checks the error details of the static responses, their types are resolved
+// by the registry of all the loaded packages, so they can't be checked while a response is decoded.
+func validateStaticMocks(mocks map[string]Mock, registry *Registry) error {
+	for _, mock := range mocks {
+		if mock.Response.IsNone() {
+			continue
+		}
+
+		if err := mock.Response.Unwrap().validateDetails(registry); err != nil {
+			return fmt.Errorf("static mock %s: %w", mock.File, err)
+		}
+	}
+
+	return nil
+}
 
-			response, err := mock.Eval(ctx, request)
-			if err != nil {
-				return nil, fmt.Errorf("evaluate mock: %w", err)
+// scriptError wraps an error of a mock script, an interrupted script is reported as DEADLINE_EXCEEDED.
+func scriptError(message string, err error) error {
+	if errors.Is(err, js.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return status.Errorf(codes.DeadlineExceeded, "%s: %v", message, err) //nolint:wrapcheck // plain gRPC error
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// fullMethodName returns the method name as it is seen by the server, e.g. /example.ExampleService/SayHello.
+func fullMethodName(method protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+}
+
+// ----------------------------------------------------------------------------
+
+func (h *Handlers) handleUnary(stream grpc.ServerStream, mock Mock, registry *Registry, call *call) error {
+	ctx := stream.Context()
+	method := mock.ProtoMethod
+
+	// Receive the only request message.
+	req := dynamicpb.NewMessage(method.Input())
+	if err := stream.RecvMsg(req); err != nil {
+		return err //nolint:wrapcheck // plain gRPC error
+	}
+
+	request, err := NewMockRequestFrom(ctx, req)
+	if err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	call.request = request
+
+	response, err := mock.Eval(ctx, h.runtimes, h.timeouts.Get(fullMethodName(method)), request)
+	if err != nil {
+		return scriptError("evaluate mock", err)
+	}
+
+	call.response = response
+
+	if err = response.Wait(ctx); err != nil {
+		return err
+	}
+
+	flt, err := h.fault(method, response)
+	if err != nil {
+		return err
+	}
+
+	release, err := h.breakCall(ctx, flt)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err = response.SetMetadata(ctx); err != nil {
+		return fmt.Errorf("set response metadata: %w", err)
+	}
+
+	// Create a dynamic response message and send it.
+	message, err := response.GRPC(method.Output(), registry)
+	if err != nil {
+		return err
+	}
+
+	return sendMessage(stream, flt, message)
+}
+
+func (h *Handlers) handleServerStream(stream grpc.ServerStream, mock Mock, registry *Registry, call *call) error {
+	ctx := stream.Context()
+	method := mock.ProtoMethod
+
+	// Receive the only request message.
+	req := dynamicpb.NewMessage(method.Input())
+	if err := stream.RecvMsg(req); err != nil {
+		return err //nolint:wrapcheck // plain gRPC error
+	}
+
+	request, err := NewMockRequestFrom(ctx, req)
+	if err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	call.request = request
+
+	mockStream := NewMockStream(stream, method.Output(), registry)
+	defer func() { call.response = mockStream.Sent() }()
+
+	response, err := mock.EvalServerStream(ctx, h.runtimes, h.timeouts.Get(fullMethodName(method)), request, mockStream)
+	if err != nil {
+		return scriptError("evaluate mock", err)
+	}
+
+	// The script has closed the stream itself, so the returned messages are not sent.
+	if mockStream.Closed() {
+		return mockStream.Err()
+	}
+
+	if err = response.Wait(ctx); err != nil {
+		return err
+	}
+
+	flt, err := h.fault(method, response)
+	if err != nil {
+		return err
+	}
+
+	release, err := h.breakCall(ctx, flt)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// The headers are sent with the first message, so the ones returned after stream.send go with the trailers.
+	if len(mockStream.Sent()) > 0 {
+		response = response.headersAsTrailers()
+	}
+
+	if err = response.SetMetadata(ctx); err != nil {
+		return fmt.Errorf("set response metadata: %w", err)
+	}
+
+	// Send the messages returned by the script, if any, the body faults break them.
+	return response.Stream(mockStream, flt)
+}
+
+func (h *Handlers) handleClientStream(stream grpc.ServerStream, mock Mock, registry *Registry, call *call) error {
+	ctx := stream.Context()
+	method := mock.ProtoMethod
+
+	// Receive all the request messages until the client closes the stream.
+	var reqs []*dynamicpb.Message
+
+	for {
+		req := dynamicpb.NewMessage(method.Input())
+
+		err := stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err //nolint:wrapcheck // plain gRPC error
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	request, err := NewMockStreamRequestFrom(ctx, reqs)
+	if err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	call.request = request
+
+	response, err := mock.Eval(ctx, h.runtimes, h.timeouts.Get(fullMethodName(method)), request)
+	if err != nil {
+		return scriptError("evaluate mock", err)
+	}
+
+	call.response = response
+
+	if err = response.Wait(ctx); err != nil {
+		return err
+	}
+
+	flt, err := h.fault(method, response)
+	if err != nil {
+		return err
+	}
+
+	release, err := h.breakCall(ctx, flt)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err = response.SetMetadata(ctx); err != nil {
+		return fmt.Errorf("set response metadata: %w", err)
+	}
+
+	// Create a dynamic response message and send it.
+	message, err := response.GRPC(method.Output(), registry)
+	if err != nil {
+		return err
+	}
+
+	return sendMessage(stream, flt, message)
+}
+
+func (h *Handlers) handleBidiStream(stream grpc.ServerStream, mock Mock, registry *Registry, call *call) error {
+	ctx := stream.Context()
+	method := mock.ProtoMethod
+
+	// Only metadata is available until the messages are received.
+	request, err := NewMockStreamRequestFrom(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	call.request = request
+
+	mockStream := NewMockStream(stream, method.Output(), registry)
+	defer func() { call.response = mockStream.Sent() }()
+
+	session, err := mock.StartSession(ctx, h.runtimes, h.timeouts.Get(fullMethodName(method)), request, mockStream)
+	if err != nil {
+		return scriptError("start mock session", err)
+	}
+	defer session.Close()
+
+	// Pass the messages to the script until either side closes the stream.
+	for !mockStream.Closed() {
+		req := dynamicpb.NewMessage(method.Input())
+
+		err = stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			if err = session.End(); err != nil {
+				return scriptError("end mock session", err)
 			}
 
-			// Create a dynamic response message.
-			return response.GRPC(outputType)
+			break
 		}
 
-		grpcMethods = append(grpcMethods, grpc.MethodDesc{
-			MethodName: methodName,
-			Handler: func(
-				srv any,
-				ctx context.Context,
-				decode func(any) error,
-				intercept grpc.UnaryServerInterceptor,
-			) (any, error) {
-				// Decode the request.
-				req := dynamicpb.NewMessage(inputType)
-				if err := decode(req); err != nil {
-					return nil, err
-				}
-
-				// Call the interceptor.
-				if intercept != nil {
-					return intercept(ctx, req, &grpc.UnaryServerInfo{
-						Server:     srv,
-						FullMethod: string(method.FullName()),
-					}, func(ctx context.Context, req any) (any, error) {
-						// Call the handler.
-						return handler(ctx, req)
-					})
-				}
-
-				// If no interceptor is provided, call the handler directly.
-				return handler(ctx, req)
-			},
-		})
+		if err != nil {
+			return err //nolint:wrapcheck // plain gRPC error
+		}
+
+		body, err := dynamic.MessageToMap(req)
+		if err != nil {
+			return fmt.Errorf("decode proto body: %w", err)
+		}
+
+		err = session.Message(body)
+		call.request = session.Request()
+
+		if err != nil {
+			return scriptError("handle mock session message", err)
+		}
 	}
 
-	// Register the handler with the server.
-	server.RegisterService(&grpc.ServiceDesc{ //nolint:exhaustruct // only required fields
-		ServiceName: string(service.ProtoService.FullName()),
-		HandlerType: (*any)(nil),
-		Methods:     grpcMethods,
-	}, nil)
+	return mockStream.Err()
+}
+
+// ----------------------------------------------------------------------------
+
+// call collects the request and the response of a handled call for the journal.
+type call struct {
+	request  any
+	response any
+}
+
+func newJournalEntry(ctx context.Context, fullMethod string, start time.Time, err error, call call) journal.Entry {
+	return journal.Entry{
+		Time:      start,
+		Protocol:  journal.ProtocolGRPC,
+		Method:    fullMethod,
+		Route:     fullMethod,
+		Path:      fullMethod,
+		Status:    int(status.Code(err)),
+		LatencyMs: time.Since(start).Milliseconds(),
+		RequestID: requestid.GetRequestID(ctx),
+		Request:   call.request,
+		Response:  call.response,
+	}
 }
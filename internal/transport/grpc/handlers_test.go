@@ -0,0 +1,665 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/grpc/codec"
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/log"
+	"github.com/sknv/protomock/pkg/net/conntrack"
+	"github.com/sknv/protomock/pkg/option"
+)
+
+const _testProto = `syntax = "proto3";
+
+package test;
+
+service TestService {
+  rpc Unary (Request) returns (Response);
+  rpc ServerStream (Request) returns (stream Response);
+  rpc ClientStream (stream Request) returns (Response);
+  rpc BidiStream (stream Request) returns (stream Response);
+}
+
+message Request {
+  string name = 1;
+}
+
+message Response {
+  string message = 1;
+}
+`
+
+// testServer serves the mocks of the test proto in memory.
+type testServer struct {
+	mocksDir string
+	conn     *grpc.ClientConn
+	handlers *Handlers
+	input    protoreflect.MessageDescriptor
+	output   protoreflect.MessageDescriptor
+}
+
+// testOptions configure the test server, the zero value serves the mocks only.
+type testOptions struct {
+	faults   map[string]fault.Fault
+	timeout  time.Duration
+	upstream option.Option[*Upstream]
+	recorder option.Option[*Recorder]
+}
+
+// startTestServer serves the scripts of the test service by the file names, e.g. Unary.js.
+func startTestServer(t *testing.T, scripts map[string]string, opts testOptions) *testServer {
+	t.Helper()
+
+	ctx := log.ToContext(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	mocksDir := t.TempDir()
+	writeFile(t, filepath.Join(mocksDir, "test", "service.proto"), _testProto)
+
+	for name, script := range scripts {
+		writeFile(t, filepath.Join(mocksDir, "test", "TestService", name), script)
+	}
+
+	packages, err := BuildPackages(ctx, mocksDir, "")
+	if err != nil {
+		t.Fatalf("BuildPackages() error = %v", err)
+	}
+
+	registry := packages.Registry(ctx)
+
+	conns := conntrack.NewTracker()
+
+	handlers, err := NewHandlers(
+		packages, registry, option.Some(journal.New(100)), js.NewPool(1, 0, nil),
+		opts.upstream, opts.recorder, opts.faults, js.Timeouts{Default: opts.timeout, ByKey: nil}, conns,
+	)
+	if err != nil {
+		t.Fatalf("NewHandlers() error = %v", err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnknownServiceHandler(handlers.Handle),
+		grpc.ForceServerCodecV2(codec.NewRawCodec()),
+	)
+
+	go func() { _ = server.Serve(conns.Listener(listener)) }()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	input, err := registry.FindMessageByName("test.Request")
+	if err != nil {
+		t.Fatalf("FindMessageByName() error = %v", err)
+	}
+
+	output, err := registry.FindMessageByName("test.Response")
+	if err != nil {
+		t.Fatalf("FindMessageByName() error = %v", err)
+	}
+
+	return &testServer{
+		mocksDir: mocksDir,
+		conn:     conn,
+		handlers: handlers,
+		input:    input.Descriptor(),
+		output:   output.Descriptor(),
+	}
+}
+
+// request builds a request message with the name.
+func (s *testServer) request(name string) *dynamicpb.Message {
+	msg := dynamicpb.NewMessage(s.input)
+	msg.Set(s.input.Fields().ByName("name"), protoreflect.ValueOfString(name))
+
+	return msg
+}
+
+// unary calls the unary method returning the response message.
+func (s *testServer) unary(ctx context.Context, name string) (string, error) {
+	response := dynamicpb.NewMessage(s.output)
+	if err := s.conn.Invoke(ctx, "/test.TestService/Unary", s.request(name), response); err != nil {
+		return "", err //nolint:wrapcheck // checked by the tests
+	}
+
+	return messageOf(response), nil
+}
+
+// stream opens a stream of the method, e.g. ServerStream.
+func (s *testServer) stream(ctx context.Context, t *testing.T, method string) grpc.ClientStream {
+	t.Helper()
+
+	//nolint:exhaustruct // only the stream kinds are required
+	desc := &grpc.StreamDesc{
+		StreamName:    method,
+		ServerStreams: method != "ClientStream",
+		ClientStreams: method != "ServerStream",
+	}
+
+	stream, err := s.conn.NewStream(ctx, desc, "/test.TestService/"+method)
+	if err != nil {
+		t.Fatalf("NewStream() error = %v", err)
+	}
+
+	return stream
+}
+
+// recv receives a response message from the stream.
+func (s *testServer) recv(stream grpc.ClientStream) (string, error) {
+	response := dynamicpb.NewMessage(s.output)
+	if err := stream.RecvMsg(response); err != nil {
+		return "", err //nolint:wrapcheck // checked by the tests
+	}
+
+	return messageOf(response), nil
+}
+
+func messageOf(response *dynamicpb.Message) string {
+	return response.Get(response.Descriptor().Fields().ByName("message")).String()
+}
+
+func TestHandleServerStream(t *testing.T) {
+	t.Parallel()
+
+	server := startTestServer(t, map[string]string{"ServerStream.js": `(function () {
+  stream.send({ message: "Hello, " + request.body.name })
+
+  return {
+    messages: [
+      { body: { message: "How are you?" }, delay: 50 },
+      { body: { message: "Goodbye" }, delay: 50 }
+    ]
+  }
+})()`}, testOptions{}) //nolint:exhaustruct
+
+	start := time.Now()
+	stream := server.serverStream(t.Context(), t, "ServerStream", "John")
+
+	var got []string
+
+	for {
+		message, err := server.recv(stream)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("recv() error = %v", err)
+		}
+
+		got = append(got, message)
+	}
+
+	want := []string{"Hello, John", "How are you?", "Goodbye"}
+	if !slices.Equal(got, want) {
+		t.Errorf("messages = %v, want %v", got, want)
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("messages are sent in %s, want at least the sum of the delays", elapsed)
+	}
+}
+
+func TestHandleServerStreamClose(t *testing.T) {
+	t.Parallel()
+
+	// The returned messages are not sent once the script has closed the stream.
+	server := startTestServer(t, map[string]string{"ServerStream.js": `(function () {
+  stream.send({ message: "first" })
+  stream.close({ code: 5, message: "not found" })
+
+  return { messages: [{ body: { message: "second" } }] }
+})()`}, testOptions{}) //nolint:exhaustruct
+
+	stream := server.serverStream(t.Context(), t, "ServerStream", "John")
+
+	if got, err := server.recv(stream); err != nil || got != "first" {
+		t.Fatalf("recv() = %q, %v, want %q", got, err, "first")
+	}
+
+	if _, err := server.recv(stream); status.Code(err) != codes.NotFound {
+		t.Errorf("recv() error = %v, want code %s", err, codes.NotFound)
+	}
+}
+
+func TestHandleServerStreamMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		send         bool // Send a message before returning.
+		wantHeaders  metadata.MD
+		wantTrailers metadata.MD
+	}{
+		{
+			name:         "returned headers",
+			send:         false,
+			wantHeaders:  metadata.Pairs("x-header", "header"),
+			wantTrailers: metadata.Pairs("x-trailer", "trailer"),
+		},
+		{
+			name:         "headers returned after send",
+			send:         true,
+			wantHeaders:  metadata.MD{},
+			wantTrailers: metadata.Pairs("x-header", "header", "x-trailer", "trailer"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The headers are sent with the first message, so the ones returned later go with the trailers.
+			server := startTestServer(t, map[string]string{"ServerStream.js": fmt.Sprintf(`(function () {
+  if (%t) {
+    stream.send({ message: "first" })
+  }
+
+  return {
+    headers: { "x-header": "header" },
+    trailers: { "x-trailer": "trailer" },
+    messages: [{ body: { message: "second" } }]
+  }
+})()`, tt.send)}, testOptions{}) //nolint:exhaustruct
+
+			stream := server.serverStream(t.Context(), t, "ServerStream", "John")
+
+			for {
+				_, err := server.recv(stream)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					t.Fatalf("recv() error = %v", err)
+				}
+			}
+
+			headers, err := stream.Header()
+			if err != nil {
+				t.Fatalf("Header() error = %v", err)
+			}
+
+			delete(headers, "content-type")
+
+			if !maps.EqualFunc(headers, tt.wantHeaders, slices.Equal) {
+				t.Errorf("Header() = %v, want %v", headers, tt.wantHeaders)
+			}
+
+			if trailers := stream.Trailer(); !maps.EqualFunc(trailers, tt.wantTrailers, slices.Equal) {
+				t.Errorf("Trailer() = %v, want %v", trailers, tt.wantTrailers)
+			}
+		})
+	}
+}
+
+func TestHandleClientStream(t *testing.T) {
+	t.Parallel()
+
+	server := startTestServer(t, map[string]string{"ClientStream.js": `(function () {
+  let names = request.messages.map((msg) => msg.name)
+
+  return { body: { message: names.length + ": " + names.join(", ") } }
+})()`}, testOptions{}) //nolint:exhaustruct
+
+	stream := server.stream(t.Context(), t, "ClientStream")
+
+	for _, name := range []string{"John", "Jane", "Jack"} {
+		if err := stream.SendMsg(server.request(name)); err != nil {
+			t.Fatalf("SendMsg() error = %v", err)
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend() error = %v", err)
+	}
+
+	got, err := server.recv(stream)
+	if err != nil {
+		t.Fatalf("recv() error = %v", err)
+	}
+
+	if want := "3: John, Jane, Jack"; got != want {
+		t.Errorf("recv() = %q, want %q", got, want)
+	}
+}
+
+const _bidiScript = `let count = 0
+
+function onMessage(msg, stream) {
+  count++
+
+  if (!msg.name) {
+    stream.close({ code: 3, message: "name is required" })
+    return
+  }
+
+  stream.send({ message: msg.name + " #" + count + " of " + request.messages.length })
+}
+
+function onEnd(stream) {
+  stream.send({ message: "Goodbye after " + count })
+}
+`
+
+func TestHandleBidiStream(t *testing.T) {
+	t.Parallel()
+
+	// The same runtime serves the streams one by one, so the state of a stream must not leak into the next one.
+	server := startTestServer(t, map[string]string{"BidiStream.js": _bidiScript}, testOptions{}) //nolint:exhaustruct
+
+	for range 2 {
+		stream := server.stream(t.Context(), t, "BidiStream")
+
+		for i, name := range []string{"John", "Jane"} {
+			if err := stream.SendMsg(server.request(name)); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+
+			got, err := server.recv(stream)
+			if want := fmt.Sprintf("%s #%d of %d", name, i+1, i+1); err != nil || got != want {
+				t.Fatalf("recv() = %q, %v, want %q", got, err, want)
+			}
+		}
+
+		if err := stream.CloseSend(); err != nil {
+			t.Fatalf("CloseSend() error = %v", err)
+		}
+
+		if got, err := server.recv(stream); err != nil || got != "Goodbye after 2" {
+			t.Fatalf("recv() = %q, %v, want %q", got, err, "Goodbye after 2")
+		}
+
+		if _, err := server.recv(stream); !errors.Is(err, io.EOF) {
+			t.Fatalf("recv() error = %v, want %v", err, io.EOF)
+		}
+	}
+}
+
+func TestHandleBidiStreamClose(t *testing.T) {
+	t.Parallel()
+
+	server := startTestServer(t, map[string]string{"BidiStream.js": _bidiScript}, testOptions{}) //nolint:exhaustruct
+
+	stream := server.stream(t.Context(), t, "BidiStream")
+	if err := stream.SendMsg(server.request("")); err != nil {
+		t.Fatalf("SendMsg() error = %v", err)
+	}
+
+	if _, err := server.recv(stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("recv() error = %v, want code %s", err, codes.InvalidArgument)
+	}
+}
+
+func TestHandleStreamCancel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		script string
+	}{
+		{
+			name:   "server stream returned messages",
+			method: "ServerStream",
+			script: `({ messages: [{ body: { message: "first" } }, { body: { message: "second" }, delay: 60000 }] })`,
+		},
+		{
+			name:   "server stream sent messages",
+			method: "ServerStream",
+			script: `stream.send({ message: "first" }); stream.send({ message: "second" }, 60000); ({})`,
+		},
+		{
+			name:   "bidirectional stream",
+			method: "BidiStream",
+			script: `function onMessage(msg, stream) { stream.send({ message: "first" }); stream.send({ message: "second" }, 60000) }`, //nolint:lll
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := startTestServer(t, map[string]string{tt.method + ".js": tt.script}, testOptions{}) //nolint:exhaustruct
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			stream := server.stream(ctx, t, tt.method)
+			if err := stream.SendMsg(server.request("John")); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+
+			if got, err := server.recv(stream); err != nil || got != "first" {
+				t.Fatalf("recv() = %q, %v, want %q", got, err, "first")
+			}
+
+			cancel()
+
+			if _, err := server.recv(stream); status.Code(err) != codes.Canceled {
+				t.Errorf("recv() error = %v, want code %s", err, codes.Canceled)
+			}
+
+			// The handler stops waiting for the delay right away.
+			server.waitCall(t, "/test.TestService/"+tt.method, codes.Canceled)
+		})
+	}
+}
+
+func TestHandleBidiStreamTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := startTestServer(t, map[string]string{
+		"BidiStream.js": `function onMessage(msg, stream) { for (;;) {} }`,
+	}, testOptions{timeout: 100 * time.Millisecond}) //nolint:exhaustruct
+
+	stream := server.stream(t.Context(), t, "BidiStream")
+	if err := stream.SendMsg(server.request("John")); err != nil {
+		t.Fatalf("SendMsg() error = %v", err)
+	}
+
+	if _, err := server.recv(stream); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("recv() error = %v, want code %s", err, codes.DeadlineExceeded)
+	}
+}
+
+func TestHandleStreamSendTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		script string
+	}{
+		{
+			name:   "server stream",
+			method: "ServerStream",
+			script: `stream.send({ message: "first" }, 60000); ({})`,
+		},
+		{
+			name:   "bidirectional stream",
+			method: "BidiStream",
+			script: `function onMessage(msg, stream) { stream.send({ message: "first" }, 60000) }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := startTestServer(t, map[string]string{
+				tt.method + ".js": tt.script,
+			}, testOptions{timeout: 100 * time.Millisecond}) //nolint:exhaustruct
+
+			// The delay ends along with the script rather than with the stream.
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			stream := server.stream(ctx, t, tt.method)
+			if err := stream.SendMsg(server.request("John")); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+
+			if _, err := server.recv(stream); status.Code(err) != codes.DeadlineExceeded || ctx.Err() != nil {
+				t.Errorf("recv() error = %v, want code %s before the stream deadline", err, codes.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+func TestHandlersReload(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.ToContext(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	server := startTestServer(t, map[string]string{
+		"Unary.js": `({ body: { message: require("greeting")(request.body.name) } })`,
+	}, testOptions{}) //nolint:exhaustruct
+	writeFile(t, filepath.Join(server.mocksDir, "lib", "greeting.js"), `module.exports = (name) => "Hello, " + name`)
+
+	// The steps share the mocks dir and run in order, a failed build keeps the previous mocks like the watcher does.
+	steps := []struct {
+		name    string
+		file    string // Relative to the mocks dir.
+		content string
+		wantErr bool
+		want    string
+	}{
+		{name: "initial", file: "", content: "", wantErr: false, want: "Hello, John"},
+		{name: "lib module change", file: "lib/greeting.js", content: `module.exports = (name) => "Hi, " + name`, wantErr: false, want: "Hi, John"},                 //nolint:lll
+		{name: "mock change", file: "test/TestService/Unary.js", content: `({ body: { message: require("greeting")("Jane") } })`, wantErr: false, want: "Hi, Jane"}, //nolint:lll
+		{name: "broken mock", file: "test/TestService/Unary.js", content: `({ body: `, wantErr: true, want: "Hi, Jane"},
+		{name: "broken lib module", file: "lib/greeting.js", content: `module.exports = (`, wantErr: true, want: "Hi, Jane"},
+		{name: "broken proto", file: "test/service.proto", content: `syntax = "proto3"; message {`, wantErr: true, want: "Hi, Jane"}, //nolint:lll
+	}
+
+	for _, step := range steps {
+		if step.file != "" {
+			writeFile(t, filepath.Join(server.mocksDir, step.file), step.content)
+		}
+
+		packages, err := BuildPackages(ctx, server.mocksDir, "")
+		if err == nil {
+			err = server.handlers.Reload(packages, packages.Registry(ctx))
+		}
+
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: reload error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		if got, err := server.unary(t.Context(), "John"); err != nil || got != step.want {
+			t.Errorf("%s: Unary() = %q, %v, want %q", step.name, got, err, step.want)
+		}
+	}
+}
+
+// serverStream opens a server stream of the method sending the only request.
+func (s *testServer) serverStream(ctx context.Context, t *testing.T, method, name string) grpc.ClientStream {
+	t.Helper()
+
+	stream := s.stream(ctx, t, method)
+	if err := stream.SendMsg(s.request(name)); err != nil {
+		t.Fatalf("SendMsg() error = %v", err)
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend() error = %v", err)
+	}
+
+	return stream
+}
+
+// waitCall waits for the call of the method to be journaled with the code.
+func (s *testServer) waitCall(t *testing.T, fullMethod string, code codes.Code) {
+	t.Helper()
+
+	want := int(code)
+	filter := journal.Filter{Method: fullMethod, Status: &want} //nolint:exhaustruct
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if s.handlers.journal.Unwrap().Count(filter) > 0 {
+			return
+		}
+	}
+
+	t.Errorf("call %s with code %s is not journaled", fullMethod, code)
+}
+
+func TestNewHandlersStaticErrorDetails(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.ToContext(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name    string
+		detail  string
+		wantErr bool
+	}{
+		{name: "known detail", detail: `{"type": "google.rpc.ErrorInfo", "body": {"reason": "INVALID"}}`, wantErr: false},
+		{name: "proto detail", detail: `{"type": "test.Response", "body": {"message": "Invalid"}}`, wantErr: false},
+		{name: "unknown type", detail: `{"type": "google.rpc.Unknown", "body": {}}`, wantErr: true},
+		{name: "unknown field", detail: `{"type": "google.rpc.ErrorInfo", "body": {"unknown": 1}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mocksDir := t.TempDir()
+			file := filepath.Join(mocksDir, "test", "TestService", "Unary.json")
+			writeFile(t, filepath.Join(mocksDir, "test", "service.proto"), _testProto)
+			writeFile(t, file, `{"error": {"code": 3, "message": "invalid", "details": [`+tt.detail+`]}}`)
+
+			packages, err := BuildPackages(ctx, mocksDir, "")
+			if err != nil {
+				t.Fatalf("BuildPackages() error = %v", err)
+			}
+
+			_, err = NewHandlers(
+				packages, packages.Registry(ctx), option.None[*journal.Journal](), js.NewPool(1, 0, nil),
+				option.None[*Upstream](), option.None[*Recorder](), nil, js.Timeouts{Default: 0, ByKey: nil},
+				conntrack.NewTracker(),
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHandlers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), file) {
+				t.Errorf("NewHandlers() error = %v, want the file %s", err, file)
+			}
+		})
+	}
+}
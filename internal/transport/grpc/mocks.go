@@ -2,16 +2,23 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/bufbuild/protocompile"
 	"github.com/bufbuild/protocompile/linker"
+	"github.com/dop251/goja"
 	"google.golang.org/protobuf/reflect/protoreflect"
 
+	"github.com/sknv/protomock/pkg/document"
 	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/log"
+	"github.com/sknv/protomock/pkg/option"
 	xstrings "github.com/sknv/protomock/pkg/strings"
 )
 
@@ -20,11 +27,20 @@ const (
 	_protoFileExtension = ".proto"
 
 	_protoIncludePath = "./include"
+
+	_onMessageHandler = "onMessage"
+	_onEndHandler     = "onEnd"
 )
 
+var errDuplicateMock = errors.New("duplicate mock")
+
 type Mock struct {
 	ProtoMethod protoreflect.MethodDescriptor
-	Script      string
+	File        string                      // Script or static response file path.
+	Script      string                      // Script or static response source, compiled once the method is known.
+	Program     *goja.Program               // Compiled script, nil for a static response.
+	Response    option.Option[MockResponse] // Static response of a JSON or YAML file.
+	Modules     *js.Modules                 // Modules the script may require.
 }
 
 type Mocks []Mock
@@ -49,21 +65,111 @@ type Package struct {
 
 type Packages []Package
 
-func (m Mock) Eval(ctx context.Context, request MockRequest) (MockResponse, error) {
-	vm := js.NewRuntime()
+// Eval evaluates the mock script in a pooled runtime providing the request, a static response is returned as is.
+// The script is interrupted once the context is done or the timeout, if any, expires.
+func (m Mock) Eval(
+	ctx context.Context, runtimes *js.Pool, timeout time.Duration, request MockRequest,
+) (MockResponse, error) {
+	return m.eval(ctx, runtimes, timeout, js.Globals{
+		"request": request,
+	}, nil)
+}
+
+// EvalServerStream evaluates a server streaming mock providing a stream to send messages on demand.
+func (m Mock) EvalServerStream(
+	ctx context.Context,
+	runtimes *js.Pool,
+	timeout time.Duration,
+	request MockRequest,
+	stream *MockStream,
+) (MockResponse, error) {
+	return m.eval(ctx, runtimes, timeout, js.Globals{
+		"request": request,
+		"stream":  stream,
+	}, stream)
+}
+
+// StartSession evaluates a bidirectional streaming mock and keeps its pooled runtime for the whole stream
+// to handle the stream events with the handlers defined by the script. The session must be closed
+// to return the runtime to the pool. The timeout limits the script evaluation and every handler call separately.
+func (m Mock) StartSession(
+	ctx context.Context,
+	runtimes *js.Pool,
+	timeout time.Duration,
+	request MockRequest,
+	stream *MockStream,
+) (*MockSession, error) {
+	vm, err := runtimes.Get()
+	if err != nil {
+		return nil, fmt.Errorf("get runtime: %w", err)
+	}
+
+	handlers, err := m.evalHandlers(ctx, vm, timeout, request, stream)
+	if err != nil {
+		runtimes.Put(vm)
+
+		return nil, err
+	}
+
+	onMessage, _ := goja.AssertFunction(handlers.Get(_onMessageHandler))
+	onEnd, _ := goja.AssertFunction(handlers.Get(_onEndHandler))
+
+	return &MockSession{
+		ctx:       ctx,
+		runtimes:  runtimes,
+		timeout:   timeout,
+		file:      m.File,
+		vm:        vm,
+		request:   request,
+		stream:    stream,
+		onMessage: onMessage,
+		onEnd:     onEnd,
+	}, nil
+}
+
+// evalHandlers evaluates a bidirectional streaming mock returning the object of the handlers it defines.
+func (m Mock) evalHandlers(
+	ctx context.Context,
+	vm *goja.Runtime,
+	timeout time.Duration,
+	request MockRequest,
+	stream *MockStream,
+) (*goja.Object, error) {
+	if err := js.SetCallGlobals(ctx, vm, m.Modules, js.Globals{
+		"request": request,
+		"stream":  stream,
+	}); err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped
+	}
+
+	handlers, err := m.run(ctx, vm, timeout, stream)
+	if err != nil {
+		return nil, fmt.Errorf("eval script %s: %w", m.File, err)
+	}
+
+	return handlers.ToObject(vm), nil
+}
+
+func (m Mock) eval(
+	ctx context.Context, runtimes *js.Pool, timeout time.Duration, globals js.Globals, stream *MockStream,
+) (MockResponse, error) {
+	if m.Response.IsSome() {
+		return m.Response.Unwrap(), nil
+	}
 
-	console := js.NewConsole(ctx)
-	if err := vm.Set("console", console); err != nil {
-		return MockResponse{}, fmt.Errorf("set console in runtime: %w", err)
+	vm, err := runtimes.Get()
+	if err != nil {
+		return MockResponse{}, fmt.Errorf("get runtime: %w", err)
 	}
+	defer runtimes.Put(vm)
 
-	if err := vm.Set("request", request); err != nil {
-		return MockResponse{}, fmt.Errorf("set request in runtime: %w", err)
+	if err = js.SetCallGlobals(ctx, vm, m.Modules, globals); err != nil {
+		return MockResponse{}, err
 	}
 
-	eval, err := vm.RunString(m.Script)
+	eval, err := m.run(ctx, vm, timeout, stream)
 	if err != nil {
-		return MockResponse{}, fmt.Errorf("eval script: %w", err)
+		return MockResponse{}, fmt.Errorf("eval script %s: %w", m.File, err)
 	}
 
 	var response MockResponse
@@ -74,6 +180,87 @@ func (m Mock) Eval(ctx context.Context, request MockRequest) (MockResponse, erro
 	return response, nil
 }
 
+// run runs the script like js.Run, the delays of the messages sent by the stream, if any, end along with the script.
+func (m Mock) run(ctx context.Context, vm *goja.Runtime, timeout time.Duration, stream *MockStream) (goja.Value, error) {
+	if stream == nil {
+		return js.Run(ctx, vm, m.Program, timeout) //nolint:wrapcheck // wrapped by the callers
+	}
+
+	var result goja.Value
+
+	err := stream.guard(ctx, vm, timeout, func() error {
+		var err error
+		result, err = vm.RunProgram(m.Program)
+
+		return err //nolint:wrapcheck // wrapped by the callers
+	})
+
+	return result, err
+}
+
+// MockSession handles the events of a bidirectional stream calling the script handlers.
+type MockSession struct {
+	ctx       context.Context //nolint:containedctx // lives as long as the stream
+	runtimes  *js.Pool
+	timeout   time.Duration
+	file      string
+	vm        *goja.Runtime
+	request   MockRequest
+	stream    *MockStream
+	onMessage goja.Callable // Optional.
+	onEnd     goja.Callable // Optional.
+}
+
+// Request returns the request including the messages received so far.
+func (s *MockSession) Request() MockRequest {
+	return s.request
+}
+
+// Message adds a received message to the request messages and passes it to the onMessage handler.
+func (s *MockSession) Message(body MockRequestBody) error {
+	s.request.Messages = append(s.request.Messages, body)
+
+	if err := s.vm.Set("request", s.request); err != nil {
+		return fmt.Errorf("set request in runtime: %w", err)
+	}
+
+	if s.onMessage == nil {
+		return nil
+	}
+
+	if err := s.stream.guard(s.ctx, s.vm, s.timeout, func() error {
+		_, err := s.onMessage(goja.Undefined(), s.vm.ToValue(body), s.vm.ToValue(s.stream))
+
+		return err //nolint:wrapcheck // wrapped below
+	}); err != nil {
+		return fmt.Errorf("call %s handler of %s: %w", _onMessageHandler, s.file, err)
+	}
+
+	return nil
+}
+
+// End notifies the onEnd handler that the client has closed the stream.
+func (s *MockSession) End() error {
+	if s.onEnd == nil {
+		return nil
+	}
+
+	if err := s.stream.guard(s.ctx, s.vm, s.timeout, func() error {
+		_, err := s.onEnd(goja.Undefined(), s.vm.ToValue(s.stream))
+
+		return err //nolint:wrapcheck // wrapped below
+	}); err != nil {
+		return fmt.Errorf("call %s handler of %s: %w", _onEndHandler, s.file, err)
+	}
+
+	return nil
+}
+
+// Close returns the runtime to the pool, the session can't be used afterwards.
+func (s *MockSession) Close() {
+	s.runtimes.Put(s.vm)
+}
+
 // ----------------------------------------------------------------------------
 
 type mockID struct {
@@ -82,12 +269,14 @@ type mockID struct {
 	Method  string
 }
 
-// BuildPackages traverses the directory and populate Packages.
+// BuildPackages traverses the directory and populate Packages skipping the modules of the lib dir,
+// which is the lib inside the mocks dir by default.
 //
 //nolint:funlen // mostly basic operations
-func BuildPackages(ctx context.Context, mocksDir string) (Packages, error) {
+func BuildPackages(ctx context.Context, mocksDir, libDir string) (Packages, error) {
 	var (
-		mocks = make(map[mockID]Mock)
+		mocks   = make(map[mockID]Mock)
+		modules = js.NewModules(mocksDir, libDir)
 
 		protoFiles []linker.File
 	)
@@ -98,23 +287,27 @@ func BuildPackages(ctx context.Context, mocksDir string) (Packages, error) {
 			return fmt.Errorf("traverse path: %w", err)
 		}
 
-		// Skip directories.
+		// Skip directories and the shared modules.
 		if info.IsDir() {
+			if modules.IsLib(path) {
+				return filepath.SkipDir
+			}
+
 			return nil
 		}
 
 		// Process only files with the proper extensions.
 		ext := filepath.Ext(path)
 
-		switch ext {
-		case _mockFileExtension:
-			// Build a mock from file.
+		switch {
+		case ext == _mockFileExtension || document.IsFile(path):
+			// Build a mock from file, a static response is decoded once the method is known.
 			content, err := os.ReadFile(path)
 			if err != nil {
 				return fmt.Errorf("read file: %w", err)
 			}
 
-			method := strings.TrimSuffix(info.Name(), _mockFileExtension)
+			method := strings.TrimSuffix(info.Name(), ext)
 			service := filepath.Base(filepath.Dir(path))
 			pkg := filepath.ToSlash( // Transform OS-specific separators to slashes.
 				strings.TrimPrefix(filepath.Dir(path), filepath.Clean(mocksDir)), // Trim original mocks dir.
@@ -127,15 +320,23 @@ func BuildPackages(ctx context.Context, mocksDir string) (Packages, error) {
 				Service: service,
 				Method:  method,
 			}
+			if existing, ok := mocks[mockID]; ok {
+				return fmt.Errorf("%w: %s and %s", errDuplicateMock, existing.File, path)
+			}
+
 			mock := Mock{
 				ProtoMethod: nil, // Will be mapped later.
+				File:        path,
 				Script:      xstrings.ByteSliceToString(content),
+				Program:     nil,                         // Will be compiled later.
+				Response:    option.None[MockResponse](), // Will be decoded later.
+				Modules:     modules,
 			}
 
 			mocks[mockID] = mock
 
 			return nil
-		case _protoFileExtension:
+		case ext == _protoFileExtension:
 			curDir := filepath.Dir(path)
 			curFile := filepath.Base(path)
 
@@ -156,7 +357,61 @@ func BuildPackages(ctx context.Context, mocksDir string) (Packages, error) {
 		return nil, fmt.Errorf("filepath walk: %w", err)
 	}
 
-	return mapProtoFilesToMocks(protoFiles, mocks), nil
+	if err = modules.CompileLib(); err != nil {
+		return nil, fmt.Errorf("compile modules: %w", err)
+	}
+
+	packages := mapProtoFilesToMocks(protoFiles, mocks)
+	warnUnmappedMocks(ctx, packages, mocks)
+
+	if err = compileMocks(packages); err != nil {
+		return nil, fmt.Errorf("compile mocks: %w", err)
+	}
+
+	return packages, nil
+}
+
+// compileMocks compiles the mapped mock scripts once, so that syntax errors are reported right away.
+// Bidirectional streaming scripts define their handlers instead of returning a response.
+// Static responses are decoded and validated against the method output type instead.
+func compileMocks(packages Packages) error {
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, service := range file.Services {
+				for i, mock := range service.Mocks {
+					if document.IsFile(mock.File) {
+						response, err := decodeStaticResponse(mock.ProtoMethod, mock.File, mock.Script)
+						if err != nil {
+							return fmt.Errorf("decode static mock %s: %w", mock.File, err)
+						}
+
+						service.Mocks[i].Response = option.Some(response)
+
+						continue
+					}
+
+					compile := js.Compile
+					if mock.ProtoMethod.IsStreamingClient() && mock.ProtoMethod.IsStreamingServer() {
+						compile = compileSession
+					}
+
+					program, err := compile(mock.File, mock.Script)
+					if err != nil {
+						return err //nolint:wrapcheck // already has the file name
+					}
+
+					service.Mocks[i].Program = program
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// compileSession compiles a bidirectional streaming script defining the stream event handlers.
+func compileSession(file, script string) (*goja.Program, error) {
+	return js.CompileHandlers(file, script, _onMessageHandler, _onEndHandler) //nolint:wrapcheck // proxy
 }
 
 //nolint:ireturn,nolintlint // contract
@@ -242,3 +497,24 @@ func mapProtoServiceToMocks(
 		Mocks:        serviceMocks,
 	}
 }
+
+// warnUnmappedMocks logs the mocks which do not match any proto method, e.g. due to a typo in a path.
+func warnUnmappedMocks(ctx context.Context, packages Packages, mocks map[mockID]Mock) {
+	mapped := make(map[string]struct{}, len(mocks))
+
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, service := range file.Services {
+				for _, mock := range service.Mocks {
+					mapped[mock.File] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for _, mock := range mocks {
+		if _, ok := mapped[mock.File]; !ok {
+			log.FromContext(ctx).WarnContext(ctx, "Mock does not match any proto method", slog.String("file", mock.File))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/dop251/goja"
+
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/log"
+)
+
+// _benchScript is a mock script of a typical size: a helper, a validation and a response built from the request.
+const _benchScript = `const greetings = { en: "Hello", es: "Hola", fr: "Bonjour" };
+
+function greet(name, lang) {
+  const words = name.trim().split(/\s+/).map((word) => word[0].toUpperCase() + word.slice(1).toLowerCase());
+  const greeting = greetings[lang] || greetings.en;
+
+  return greeting + ", " + words.join(" ") + "!";
+}
+
+const lang = (request.metadata["accept-language"] || "en").split(",")[0].slice(0, 2);
+
+if (!request.body.name) {
+  ({ error: { code: 3, message: "name is required", details: [] } });
+} else {
+  const visits = [1, 2, 3, 4, 5].filter((n) => n % 2 === 1).reduce((sum, n) => sum + n, 0);
+
+  ({
+    headers: { "x-request-id": request.metadata["x-request-id"] || "none" },
+    body: { message: greet(request.body.name, lang) + " Visits: " + visits },
+  });
+}
+`
+
+// BenchmarkEval compares the evaluation of a mock the way it used to be done, i.e. running the source
+// in a new runtime on every call, with running the script compiled once in a new runtime,
+// and with Mock.Eval running the compiled script in a pooled runtime.
+func BenchmarkEval(b *testing.B) {
+	ctx := log.ToContext(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	mocksDir := b.TempDir()
+	writeFile(b, filepath.Join(mocksDir, "test", "service.proto"), _testProto)
+	writeFile(b, filepath.Join(mocksDir, "test", "TestService", "Unary.js"), _benchScript)
+
+	packages, err := BuildPackages(ctx, mocksDir, "")
+	if err != nil {
+		b.Fatalf("BuildPackages() error = %v", err)
+	}
+
+	mock := findMock(b, packages, "/test.TestService/Unary")
+
+	//nolint:exhaustruct // only the fields used by the script
+	request := MockRequest{
+		Metadata: MockRequestMetadata{"accept-language": "fr-FR,fr;q=0.9", "x-request-id": "req-1"},
+		Body:     MockRequestBody{"name": "john DOE"},
+	}
+
+	const want = "Bonjour, John Doe! Visits: 9"
+
+	// newRuntime creates a runtime the way it used to be done for every call.
+	newRuntime := func(b *testing.B) *goja.Runtime {
+		b.Helper()
+
+		vm := js.NewRuntime()
+		if err := js.SetCallGlobals(ctx, vm, mock.Modules, js.Globals{"request": request}); err != nil {
+			b.Fatalf("SetCallGlobals() error = %v", err)
+		}
+
+		return vm
+	}
+
+	export := func(b *testing.B, vm *goja.Runtime, eval goja.Value) {
+		b.Helper()
+
+		var response MockResponse
+		if err := vm.ExportTo(eval, &response); err != nil || response.Body["message"] != want {
+			b.Fatalf("ExportTo() = %+v, %v, want message %q", response, err, want)
+		}
+	}
+
+	b.Run("source per call", func(b *testing.B) {
+		for b.Loop() {
+			vm := newRuntime(b)
+
+			eval, err := vm.RunString(_benchScript)
+			if err != nil {
+				b.Fatalf("RunString() error = %v", err)
+			}
+
+			export(b, vm, eval)
+		}
+	})
+
+	b.Run("compiled once", func(b *testing.B) {
+		for b.Loop() {
+			vm := newRuntime(b)
+
+			eval, err := vm.RunProgram(mock.Program)
+			if err != nil {
+				b.Fatalf("RunProgram() error = %v", err)
+			}
+
+			export(b, vm, eval)
+		}
+	})
+
+	b.Run("compiled once and pooled", func(b *testing.B) {
+		runtimes := js.NewPool(1, 0, nil)
+
+		for b.Loop() {
+			response, err := mock.Eval(ctx, runtimes, 0, request)
+			if err != nil || response.Body["message"] != want {
+				b.Fatalf("Eval() = %+v, %v, want message %q", response, err, want)
+			}
+		}
+	})
+}
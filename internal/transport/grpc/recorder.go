@@ -0,0 +1,221 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/sknv/protomock/pkg/protobuf/dynamic"
+)
+
+const (
+	_recordedDirMode  = 0o755
+	_recordedFileMode = 0o644
+)
+
+//nolint:gochecknoglobals // constants
+var (
+	// _transientCodes are not recorded as they are most likely caused by the transport, not the upstream logic.
+	_transientCodes = []codes.Code{codes.Canceled, codes.DeadlineExceeded, codes.Unavailable}
+)
+
+var errNotRecordable = errors.New("response is not recordable")
+
+// Recorder writes the observed upstream responses of the unary calls as mocks.
+type Recorder struct {
+	mocksDir string
+}
+
+func NewRecorder(mocksDir string) *Recorder {
+	return &Recorder{
+		mocksDir: mocksDir,
+	}
+}
+
+// Record writes the response as a mock script of the method, e.g. example/ExampleService/SayHello.js.
+// The existing mocks are never overwritten, an empty file path is returned in this case.
+func (r *Recorder) Record(
+	method protoreflect.MethodDescriptor,
+	response MockResponse,
+) (string, error) {
+	if response.Error != nil && slices.Contains(_transientCodes, codes.Code(response.Error.Code)) { //nolint:gosec // determined range
+		return "", fmt.Errorf("code %s: %w", codes.Code(response.Error.Code), errNotRecordable) //nolint:gosec // determined range
+	}
+
+	service := method.Parent().(protoreflect.ServiceDescriptor) //nolint:forcetypeassert // methods belong to services
+	dir := filepath.Join(r.mocksDir, string(service.ParentFile().Package()), string(service.Name()))
+
+	// Render the script first, so that a failure doesn't leave an empty file shadowing the method.
+	script, err := recordedScript(method, response)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(dir, _recordedDirMode); err != nil {
+		return "", fmt.Errorf("create mock dir: %w", err)
+	}
+
+	filePath := filepath.Join(dir, string(method.Name())+_mockFileExtension)
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, _recordedFileMode)
+	if errors.Is(err, fs.ErrExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("create mock file: %w", err)
+	}
+
+	_, err = file.WriteString(script)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(filePath) // Let the method be recorded next time.
+
+		return "", fmt.Errorf("write mock file: %w", err)
+	}
+
+	return filePath, nil
+}
+
+// NewMockResponseFromUpstream converts the upstream response to the mock one.
+func NewMockResponseFromUpstream(resolver dynamic.TypeResolver, upstream UpstreamResponse) (MockResponse, error) {
+	response := MockResponse{
+		Headers:  recordedMetadata(upstream.Headers),
+		Trailers: recordedMetadata(upstream.Trailers),
+		Body:     nil,
+		Messages: nil,
+		Error:    nil,
+	}
+
+	if upstream.Err != nil {
+		responseErr, err := recordedError(resolver, upstream.Err)
+		if err != nil {
+			return MockResponse{}, err
+		}
+
+		response.Error = responseErr
+
+		return response, nil
+	}
+
+	body, err := dynamic.MessageToMap(upstream.Body)
+	if err != nil {
+		return MockResponse{}, fmt.Errorf("decode proto body: %w", err)
+	}
+
+	response.Body = body
+
+	return response, nil
+}
+
+// ----------------------------------------------------------------------------
+
+func recordedError(resolver dynamic.TypeResolver, err error) (*MockResponseError, error) {
+	sts := status.Convert(err)
+
+	details := make([]MockResponseErrorDetail, 0, len(sts.Proto().GetDetails()))
+
+	for _, detail := range sts.Proto().GetDetails() {
+		typeName, body, err := dynamic.AnyToMap(resolver, detail)
+		if err != nil {
+			return nil, fmt.Errorf("decode error detail: %w", err)
+		}
+
+		details = append(details, MockResponseErrorDetail{
+			Type: typeName,
+			Body: body,
+		})
+	}
+
+	return &MockResponseError{
+		Code:    int(sts.Code()),
+		Message: sts.Message(),
+		Details: details,
+	}, nil
+}
+
+// recordedMetadata converts the metadata to the script format, binary values are base64 encoded.
+func recordedMetadata(md metadata.MD) MockResponseMetadata {
+	result := make(MockResponseMetadata, len(md))
+
+	for key, values := range md {
+		if len(values) == 0 {
+			continue
+		}
+
+		value := values[0]
+		if strings.HasSuffix(key, _binaryMetadataSuffix) {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+
+		result[key] = value
+	}
+
+	return result
+}
+
+// recordedScript renders a mock script returning the response, empty fields are omitted.
+func recordedScript(method protoreflect.MethodDescriptor, response MockResponse) (string, error) {
+	fields := make(map[string]any)
+
+	if len(response.Headers) > 0 {
+		fields["headers"] = response.Headers
+	}
+
+	if len(response.Trailers) > 0 {
+		fields["trailers"] = response.Trailers
+	}
+
+	if response.Error != nil {
+		fields["error"] = recordedErrorFields(response.Error)
+	} else {
+		fields["body"] = response.Body
+	}
+
+	var data bytes.Buffer
+
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("  ", "  ")
+
+	if err := encoder.Encode(fields); err != nil {
+		return "", fmt.Errorf("encode response: %w", err)
+	}
+
+	var script strings.Builder
+
+	fmt.Fprintf(&script, "// Recorded from %s\n", method.FullName())
+	script.WriteString("(function () {\n  return ")
+	script.WriteString(strings.TrimSuffix(data.String(), "\n"))
+	script.WriteString("\n})()\n")
+
+	return script.String(), nil
+}
+
+func recordedErrorFields(responseErr *MockResponseError) map[string]any {
+	fields := map[string]any{
+		"code":    responseErr.Code,
+		"message": responseErr.Message,
+	}
+
+	if len(responseErr.Details) > 0 {
+		fields["details"] = responseErr.Details
+	}
+
+	return fields
+}
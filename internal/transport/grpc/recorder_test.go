@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"errors"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/sknv/protomock/pkg/grpc/codec"
+	"github.com/sknv/protomock/pkg/option"
+)
+
+func TestRecorderRecord(t *testing.T) {
+	t.Parallel()
+
+	method := startTestServer(t, nil, testOptions{}).handlers.routes.Load().methods["/test.TestService/Unary"] //nolint:exhaustruct,lll
+
+	tests := []struct {
+		name     string
+		existing string // Content of an existing mock file if any.
+		response MockResponse
+		wantFile bool
+		wantErr  error
+	}{
+		{name: "body", existing: "", response: MockResponse{Body: MockResponseBody{"message": "Hello"}}, wantFile: true, wantErr: nil},                   //nolint:exhaustruct,lll
+		{name: "error", existing: "", response: MockResponse{Error: &MockResponseError{Code: 3, Message: "bad"}}, wantFile: true, wantErr: nil},          //nolint:exhaustruct,lll
+		{name: "transient error", existing: "", response: MockResponse{Error: &MockResponseError{Code: 14}}, wantFile: false, wantErr: errNotRecordable}, //nolint:exhaustruct,lll
+		{name: "existing mock", existing: "({})", response: MockResponse{Body: MockResponseBody{"message": "Hello"}}, wantFile: false, wantErr: nil},     //nolint:exhaustruct,lll
+		{name: "unencodable body", existing: "", response: MockResponse{Body: MockResponseBody{"message": math.NaN()}}, wantFile: false, wantErr: nil},   //nolint:exhaustruct,lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mocksDir := t.TempDir()
+			filePath := filepath.Join(mocksDir, "test", "TestService", "Unary.js")
+
+			if tt.existing != "" {
+				writeFile(t, filePath, tt.existing)
+			}
+
+			got, err := NewRecorder(mocksDir).Record(method, tt.response)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Record() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if (got != "") != tt.wantFile {
+				t.Fatalf("Record() = %q, want file %v", got, tt.wantFile)
+			}
+
+			content, err := os.ReadFile(filePath)
+			switch {
+			case tt.existing != "":
+				if string(content) != tt.existing {
+					t.Errorf("existing mock = %q, want %q", content, tt.existing)
+				}
+			case tt.wantFile:
+				if len(content) == 0 {
+					t.Errorf("recorded mock is empty, error = %v", err)
+				}
+			default:
+				// A failed record must not leave a file shadowing the method.
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("mock file exists, error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleRecordPassesUpstream(t *testing.T) {
+	t.Parallel()
+
+	// The upstream fails with a detail of a type unknown to the mocks, so the response can't be recorded.
+	upstreamErr := status.FromProto(&spb.Status{
+		Code:    int32(codes.InvalidArgument),
+		Message: "invalid name",
+		Details: []*anypb.Any{{TypeUrl: "type.googleapis.com/unknown.Detail", Value: nil}},
+	}).Err()
+
+	upstreamServer := grpc.NewServer(
+		grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+			if err := stream.RecvMsg(new(codec.Frame)); err != nil {
+				return err //nolint:wrapcheck // plain gRPC error
+			}
+
+			return upstreamErr
+		}),
+		grpc.ForceServerCodecV2(codec.NewRawCodec()),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	go func() { _ = upstreamServer.Serve(listener) }()
+
+	t.Cleanup(upstreamServer.Stop)
+
+	upstream, err := NewUpstream(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("NewUpstream() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = upstream.Close() })
+
+	mocksDir := t.TempDir()
+	server := startTestServer(t, nil, testOptions{ //nolint:exhaustruct
+		upstream: option.Some(upstream),
+		recorder: option.Some(NewRecorder(mocksDir)),
+	})
+
+	_, err = server.unary(t.Context(), "John")
+
+	sts := status.Convert(err)
+	if sts.Code() != codes.InvalidArgument || sts.Message() != "invalid name" || len(sts.Details()) != 1 {
+		t.Errorf("Unary() error = %v, want the upstream one", err)
+	}
+
+	if entries, _ := os.ReadDir(mocksDir); len(entries) != 0 {
+		t.Errorf("recorded %d entries, want none", len(entries))
+	}
+}
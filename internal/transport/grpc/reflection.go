@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"maps"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// registerReflection serves both v1 and v1alpha reflection services backed by the loaded proto files,
+// so that clients like grpcurl could discover the mocked services.
+func registerReflection(server *grpc.Server, handlers *Handlers) {
+	source := reflectionSource{
+		server:   server,
+		handlers: handlers,
+	}
+
+	opts := reflection.ServerOptions{
+		Services:           source,
+		DescriptorResolver: source,
+		ExtensionResolver:  source,
+	}
+
+	reflectionv1.RegisterServerReflectionServer(server, reflection.NewServerV1(opts))
+	reflectionv1alpha.RegisterServerReflectionServer(server, reflection.NewServer(opts))
+}
+
+// reflectionSource provides the current services and descriptors, so that reloaded mocks are reflected too.
+type reflectionSource struct {
+	server   *grpc.Server
+	handlers *Handlers
+}
+
+// GetServiceInfo returns both the registered services and the loaded ones.
+func (s reflectionSource) GetServiceInfo() map[string]grpc.ServiceInfo {
+	services := s.server.GetServiceInfo()
+	maps.Copy(services, s.handlers.routes.Load().services)
+
+	return services
+}
+
+//nolint:ireturn // contract
+func (s reflectionSource) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	return s.registry().FindFileByPath(path)
+}
+
+//nolint:ireturn // contract
+func (s reflectionSource) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	return s.registry().FindDescriptorByName(name)
+}
+
+//nolint:ireturn // contract
+func (s reflectionSource) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return s.registry().Extensions().FindExtensionByName(name) //nolint:wrapcheck // proxy
+}
+
+//nolint:ireturn // contract
+func (s reflectionSource) FindExtensionByNumber(
+	message protoreflect.FullName,
+	field protoreflect.FieldNumber,
+) (protoreflect.ExtensionType, error) {
+	return s.registry().Extensions().FindExtensionByNumber(message, field) //nolint:wrapcheck // proxy
+}
+
+func (s reflectionSource) RangeExtensionsByMessage(
+	message protoreflect.FullName,
+	f func(protoreflect.ExtensionType) bool,
+) {
+	s.registry().Extensions().RangeExtensionsByMessage(message, f)
+}
+
+func (s reflectionSource) registry() *Registry {
+	return s.handlers.routes.Load().registry
+}
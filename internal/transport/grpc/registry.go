@@ -0,0 +1,185 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // Register the well-known google.rpc error details.
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/sknv/protomock/pkg/log"
+)
+
+// Registry holds the loaded proto files along with their imports and declared types.
+// Global descriptors, e.g. the ones linked into the binary, are used as a fallback.
+type Registry struct {
+	files *protoregistry.Files
+	types *protoregistry.Types
+}
+
+// Registry collects all the proto files of the packages including the imported ones.
+// The proto files are compiled relative to their own dirs, so different files of different packages
+// may share a path or declare the same names. Such a conflict only affects reflection: the conflicting file
+// is logged and left out of the reflected files, while its types are still resolved and its mocks served.
+func (p Packages) Registry(ctx context.Context) *Registry {
+	registry := &Registry{
+		files: new(protoregistry.Files),
+		types: new(protoregistry.Types),
+	}
+
+	for _, pkg := range p {
+		for _, file := range pkg.Files {
+			registry.registerFile(ctx, file.ProtoFile)
+		}
+	}
+
+	return registry
+}
+
+// FindFileByPath looks up a file by the path.
+//
+//nolint:ireturn // contract
+func (r *Registry) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	file, err := r.files.FindFileByPath(path)
+	if errors.Is(err, protoregistry.NotFound) {
+		return protoregistry.GlobalFiles.FindFileByPath(path) //nolint:wrapcheck // proxy
+	}
+
+	return file, err //nolint:wrapcheck // proxy
+}
+
+// FindDescriptorByName looks up a descriptor by the full name.
+//
+//nolint:ireturn // contract
+func (r *Registry) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	desc, err := r.files.FindDescriptorByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		return protoregistry.GlobalFiles.FindDescriptorByName(name) //nolint:wrapcheck // proxy
+	}
+
+	return desc, err //nolint:wrapcheck // proxy
+}
+
+// FindMessageByName looks up a message type by the full name.
+//
+//nolint:ireturn // contract
+func (r *Registry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	msgType, err := r.types.FindMessageByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		return protoregistry.GlobalTypes.FindMessageByName(name) //nolint:wrapcheck // proxy
+	}
+
+	return msgType, err //nolint:wrapcheck // proxy
+}
+
+// FindMessageByURL looks up a message type by the type URL.
+//
+//nolint:ireturn // contract
+func (r *Registry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	msgType, err := r.types.FindMessageByURL(url)
+	if errors.Is(err, protoregistry.NotFound) {
+		return protoregistry.GlobalTypes.FindMessageByURL(url) //nolint:wrapcheck // proxy
+	}
+
+	return msgType, err //nolint:wrapcheck // proxy
+}
+
+// FindExtensionByName looks up an extension type by the full name.
+//
+//nolint:ireturn // contract
+func (r *Registry) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	extType, err := r.types.FindExtensionByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		return protoregistry.GlobalTypes.FindExtensionByName(name) //nolint:wrapcheck // proxy
+	}
+
+	return extType, err //nolint:wrapcheck // proxy
+}
+
+// FindExtensionByNumber looks up an extension type by the message name and the field number.
+//
+//nolint:ireturn // contract
+func (r *Registry) FindExtensionByNumber(
+	message protoreflect.FullName,
+	field protoreflect.FieldNumber,
+) (protoreflect.ExtensionType, error) {
+	extType, err := r.types.FindExtensionByNumber(message, field)
+	if errors.Is(err, protoregistry.NotFound) {
+		return protoregistry.GlobalTypes.FindExtensionByNumber(message, field) //nolint:wrapcheck // proxy
+	}
+
+	return extType, err //nolint:wrapcheck // proxy
+}
+
+// Extensions returns the extension types declared in the loaded files.
+func (r *Registry) Extensions() *protoregistry.Types {
+	return r.types
+}
+
+// ----------------------------------------------------------------------------
+
+func (r *Registry) registerFile(ctx context.Context, file protoreflect.FileDescriptor) {
+	// Skip the files already registered, e.g. imported by several other files.
+	if registered, err := r.files.FindFileByPath(file.Path()); err == nil {
+		if !proto.Equal(protodesc.ToFileDescriptorProto(registered), protodesc.ToFileDescriptorProto(file)) {
+			log.FromContext(ctx).WarnContext(ctx, "Proto file is left out of reflection, different files share the path",
+				slog.String("path", file.Path()), slog.String("package", string(file.Package())))
+			r.registerTypes(file.Messages(), file.Enums(), file.Extensions())
+		}
+
+		return
+	}
+
+	// Register the imports first.
+	imports := file.Imports()
+	for i := range imports.Len() {
+		r.registerFile(ctx, imports.Get(i).FileDescriptor)
+	}
+
+	if err := r.files.RegisterFile(file); err != nil {
+		log.FromContext(ctx).WarnContext(ctx, "Proto file is left out of reflection",
+			slog.String("path", file.Path()), slog.Any("error", err))
+	}
+
+	r.registerTypes(file.Messages(), file.Enums(), file.Extensions())
+}
+
+// registerTypes registers the declared types skipping the names already registered, the first declaration wins.
+func (r *Registry) registerTypes(
+	messages protoreflect.MessageDescriptors,
+	enums protoreflect.EnumDescriptors,
+	extensions protoreflect.ExtensionDescriptors,
+) {
+	for i := range enums.Len() {
+		if _, err := r.types.FindEnumByName(enums.Get(i).FullName()); errors.Is(err, protoregistry.NotFound) {
+			_ = r.types.RegisterEnum(dynamicpb.NewEnumType(enums.Get(i))) // Can't conflict.
+		}
+	}
+
+	for i := range extensions.Len() {
+		extension := extensions.Get(i)
+		if _, err := r.types.FindExtensionByName(extension.FullName()); !errors.Is(err, protoregistry.NotFound) {
+			continue
+		}
+
+		_, err := r.types.FindExtensionByNumber(extension.ContainingMessage().FullName(), extension.Number())
+		if errors.Is(err, protoregistry.NotFound) {
+			_ = r.types.RegisterExtension(dynamicpb.NewExtensionType(extension)) // Can't conflict.
+		}
+	}
+
+	for i := range messages.Len() {
+		message := messages.Get(i)
+		if _, err := r.types.FindMessageByName(message.FullName()); errors.Is(err, protoregistry.NotFound) {
+			_ = r.types.RegisterMessage(dynamicpb.NewMessageType(message)) // Can't conflict.
+		}
+
+		// Register the nested types as well.
+		r.registerTypes(message.Messages(), message.Enums(), message.Extensions())
+	}
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/log"
+)
+
+func TestRegistrySharedFilePath(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.ToContext(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	mocksDir := t.TempDir()
+	for _, pkg := range []string{"alpha", "beta"} {
+		writeFile(t, filepath.Join(mocksDir, pkg, "service.proto"), `syntax = "proto3";
+package `+pkg+`;
+message Request { string name = 1; }
+message Response { string message = 1; }
+service Service { rpc Call(Request) returns (Response); }
+`)
+		writeFile(t, filepath.Join(mocksDir, pkg, "Service", "Call.js"), `({ body: { message: "`+pkg+`" } })`)
+	}
+
+	packages, err := BuildPackages(ctx, mocksDir, "")
+	if err != nil {
+		t.Fatalf("BuildPackages() error = %v", err)
+	}
+
+	registry := packages.Registry(ctx)
+
+	// Both packages are served and their types are resolved.
+	for _, pkg := range []string{"alpha", "beta"} {
+		mock := findMock(t, packages, "/"+pkg+".Service/Call")
+
+		response, err := mock.Eval(ctx, js.NewPool(1, 0, nil), 0, MockRequest{}) //nolint:exhaustruct
+		if err != nil {
+			t.Fatalf("Eval() error = %v", err)
+		}
+
+		if _, err = response.GRPC(mock.ProtoMethod.Output(), registry); err != nil {
+			t.Errorf("GRPC() of %s error = %v", pkg, err)
+		}
+
+		if _, err = registry.FindMessageByName(protoreflect.FullName(pkg + ".Response")); err != nil {
+			t.Errorf("FindMessageByName() of %s error = %v", pkg, err)
+		}
+	}
+
+	// Only one of the files sharing the path is reflected.
+	file, err := registry.FindFileByPath("service.proto")
+	if err != nil {
+		t.Fatalf("FindFileByPath() error = %v", err)
+	}
+
+	if _, err = registry.FindDescriptorByName(file.Package() + ".Service"); err != nil {
+		t.Errorf("FindDescriptorByName() error = %v", err)
+	}
+}
+
+func writeFile(tb testing.TB, path, content string) {
+	tb.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		tb.Fatalf("create dir error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		tb.Fatalf("write file error = %v", err)
+	}
+}
+
+func findMock(tb testing.TB, packages Packages, fullMethod string) Mock {
+	tb.Helper()
+
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, service := range file.Services {
+				for _, mock := range service.Mocks {
+					if fullMethodName(mock.ProtoMethod) == fullMethod {
+						return mock
+					}
+				}
+			}
+		}
+	}
+
+	tb.Fatalf("mock %s not found", fullMethod)
+
+	return Mock{} //nolint:exhaustruct // unreachable
+}
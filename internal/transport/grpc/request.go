@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/types/dynamicpb"
 
 	"github.com/sknv/protomock/pkg/protobuf/dynamic"
+	xtls "github.com/sknv/protomock/pkg/tls"
 )
 
 type (
@@ -16,8 +19,10 @@ type (
 )
 
 type MockRequest struct {
-	Metadata MockRequestMetadata `json:"metadata"`
-	Body     MockRequestBody     `json:"body"`
+	Metadata   MockRequestMetadata `json:"metadata"`
+	Body       MockRequestBody     `json:"body"`
+	Messages   []MockRequestBody   `json:"messages"`   // Used by client streaming methods only.
+	ClientCert *xtls.ClientCert    `json:"clientCert"` // Verified client certificate if mutual TLS is enabled.
 }
 
 func NewMockRequestFrom(ctx context.Context, r *dynamicpb.Message) (MockRequest, error) {
@@ -26,6 +31,51 @@ func NewMockRequestFrom(ctx context.Context, r *dynamicpb.Message) (MockRequest,
 		return MockRequest{}, fmt.Errorf("decode proto body: %w", err)
 	}
 
+	return MockRequest{
+		Metadata:   newMockRequestMetadata(ctx),
+		Body:       body,
+		Messages:   nil,
+		ClientCert: newClientCert(ctx),
+	}, nil
+}
+
+// NewMockStreamRequestFrom builds a request from all the messages received from a client stream.
+func NewMockStreamRequestFrom(ctx context.Context, rs []*dynamicpb.Message) (MockRequest, error) {
+	messages := make([]MockRequestBody, 0, len(rs))
+
+	for _, r := range rs {
+		body, err := dynamic.MessageToMap(r)
+		if err != nil {
+			return MockRequest{}, fmt.Errorf("decode proto body: %w", err)
+		}
+
+		messages = append(messages, body)
+	}
+
+	return MockRequest{
+		Metadata:   newMockRequestMetadata(ctx),
+		Body:       nil,
+		Messages:   messages,
+		ClientCert: newClientCert(ctx),
+	}, nil
+}
+
+// newClientCert returns the verified client certificate of the call if there is one.
+func newClientCert(ctx context.Context) *xtls.ClientCert {
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := client.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+
+	return xtls.NewClientCert(&info.State)
+}
+
+func newMockRequestMetadata(ctx context.Context) MockRequestMetadata {
 	md, _ := metadata.FromIncomingContext(ctx)
 	meta := make(MockRequestMetadata, len(md))
 
@@ -40,8 +90,5 @@ func NewMockRequestFrom(ctx context.Context, r *dynamicpb.Message) (MockRequest,
 		meta[key] = firstVal
 	}
 
-	return MockRequest{
-		Metadata: meta,
-		Body:     body,
-	}, nil
+	return meta
 }
@@ -1,34 +1,139 @@
 package grpc
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"maps"
+	"strings"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/dynamicpb"
 
+	"github.com/sknv/protomock/pkg/delay"
+	"github.com/sknv/protomock/pkg/document"
+	"github.com/sknv/protomock/pkg/fault"
 	"github.com/sknv/protomock/pkg/protobuf/dynamic"
+	xstrings "github.com/sknv/protomock/pkg/strings"
 )
 
-type MockResponseBody map[string]any
+const _binaryMetadataSuffix = "-bin"
+
+var (
+	errStaticBidiStream = errors.New("static responses are not supported by bidirectional streaming methods")
+	errNoStaticBody     = errors.New("either body or error is required")
+)
+
+type (
+	MockResponseBody     map[string]any
+	MockResponseMetadata map[string]string
+)
+
+type MockResponseErrorDetail struct {
+	Type string           `json:"type"` // Full message name, e.g. google.rpc.BadRequest.
+	Body MockResponseBody `json:"body"`
+}
 
 type MockResponseError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int                       `json:"code"`
+	Message string                    `json:"message"`
+	Details []MockResponseErrorDetail `json:"details"`
+}
+
+type MockResponseMessage struct {
+	Body  MockResponseBody `json:"body"`
+	Delay any              `json:"delay"` // Delay before sending the message, see delay.Parse.
 }
 
 type MockResponse struct {
-	Body  MockResponseBody   `json:"body"`
-	Error *MockResponseError `json:"error"`
+	Headers  MockResponseMetadata  `json:"headers"`
+	Trailers MockResponseMetadata  `json:"trailers"`
+	Body     MockResponseBody      `json:"body"`
+	Messages []MockResponseMessage `json:"messages"` // Used by server streaming methods only.
+	Error    *MockResponseError    `json:"error"`
+	Delay    any                   `json:"delay"` // Delay before responding, see delay.Parse.
+	Fault    any                   `json:"fault"` // Breaks the call on purpose, see fault.Parse.
 }
 
-func (r MockResponse) GRPC(response protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
-	if r.Error != nil {
-		// Create a gRPC status with error details if needed.
-		sts := status.New(codes.Code(r.Error.Code), r.Error.Message) //nolint:gosec // determined range
+// decodeStaticResponse decodes the response of a JSON or YAML file validating it against the method right away,
+// so that a broken response fails the mocks loading instead of every call.
+func decodeStaticResponse(method protoreflect.MethodDescriptor, file, source string) (MockResponse, error) {
+	if method.IsStreamingClient() && method.IsStreamingServer() {
+		return MockResponse{}, errStaticBidiStream
+	}
+
+	var response MockResponse
+	if err := document.Decode(file, xstrings.StringToByteSlice(source), &response); err != nil {
+		return MockResponse{}, fmt.Errorf("decode response: %w", err)
+	}
+
+	if err := response.validate(method); err != nil {
+		return MockResponse{}, err
+	}
+
+	return response, nil
+}
+
+// Wait pauses the call for the response delay, a DEADLINE_EXCEEDED or CANCELLED error is returned
+// if the call is done earlier.
+func (r MockResponse) Wait(ctx context.Context) error {
+	return wait(ctx, r.Delay)
+}
+
+// SetMetadata sets the response headers and trailers to be sent along with the call.
+func (r MockResponse) SetMetadata(ctx context.Context) error {
+	if len(r.Headers) > 0 {
+		headers, err := r.Headers.MD()
+		if err != nil {
+			return fmt.Errorf("encode headers: %w", err)
+		}
+
+		if err = grpc.SetHeader(ctx, headers); err != nil {
+			return fmt.Errorf("set headers: %w", err)
+		}
+	}
 
-		return nil, sts.Err() //nolint:wrapcheck // plain gRPC error
+	if len(r.Trailers) > 0 {
+		trailers, err := r.Trailers.MD()
+		if err != nil {
+			return fmt.Errorf("encode trailers: %w", err)
+		}
+
+		if err = grpc.SetTrailer(ctx, trailers); err != nil {
+			return fmt.Errorf("set trailers: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// headersAsTrailers moves the headers to the trailers, e.g. once the headers have been sent,
+// the returned trailers take precedence.
+func (r MockResponse) headersAsTrailers() MockResponse {
+	if len(r.Headers) == 0 {
+		return r
+	}
+
+	trailers := make(MockResponseMetadata, len(r.Headers)+len(r.Trailers))
+	maps.Copy(trailers, r.Headers)
+	maps.Copy(trailers, r.Trailers)
+
+	r.Headers, r.Trailers = nil, trailers
+
+	return r
+}
+
+func (r MockResponse) GRPC(
+	response protoreflect.MessageDescriptor,
+	resolver dynamic.TypeResolver,
+) (*dynamicpb.Message, error) {
+	if err := r.Err(resolver); err != nil {
+		return nil, err
 	}
 
 	// If there is no error, return a response.
@@ -39,3 +144,133 @@ func (r MockResponse) GRPC(response protoreflect.MessageDescriptor) (*dynamicpb.
 
 	return message, nil
 }
+
+// Stream sends the response messages one by one broken according to the body fault if any,
+// and then returns the response error if any.
+func (r MockResponse) Stream(stream *MockStream, flt fault.Fault) error {
+	for _, msg := range r.Messages {
+		if err := stream.send(msg.Body, msg.Delay, flt); err != nil {
+			return fmt.Errorf("send stream message: %w", err)
+		}
+	}
+
+	return r.Err(stream.resolver)
+}
+
+// validate checks the response could be sent by the method, the error details are checked by validateDetails
+// once the registry of all the loaded types is built.
+func (r MockResponse) validate(method protoreflect.MethodDescriptor) error {
+	if _, err := delay.Parse(r.Delay); err != nil {
+		return fmt.Errorf("parse delay: %w", err)
+	}
+
+	if _, err := parseFault(r.Fault); err != nil {
+		return err
+	}
+
+	if _, err := r.Headers.MD(); err != nil {
+		return fmt.Errorf("encode headers: %w", err)
+	}
+
+	if _, err := r.Trailers.MD(); err != nil {
+		return fmt.Errorf("encode trailers: %w", err)
+	}
+
+	if method.IsStreamingServer() {
+		for i, msg := range r.Messages {
+			if _, err := delay.Parse(msg.Delay); err != nil {
+				return fmt.Errorf("parse delay of message %d: %w", i, err)
+			}
+
+			if _, err := dynamic.MapToMessage(method.Output(), msg.Body); err != nil {
+				return fmt.Errorf("encode proto body of message %d: %w", i, err)
+			}
+		}
+
+		return nil
+	}
+
+	if r.Error != nil {
+		return nil
+	}
+
+	if r.Body == nil {
+		return errNoStaticBody
+	}
+
+	if _, err := dynamic.MapToMessage(method.Output(), r.Body); err != nil {
+		return fmt.Errorf("encode proto body: %w", err)
+	}
+
+	return nil
+}
+
+// validateDetails checks the error details types are known and their bodies can be encoded.
+func (r MockResponse) validateDetails(resolver dynamic.TypeResolver) error {
+	if r.Error == nil {
+		return nil
+	}
+
+	for i, detail := range r.Error.Details {
+		if _, err := dynamic.MapToAny(resolver, detail.Type, detail.Body); err != nil {
+			return fmt.Errorf("encode error detail %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Err returns a gRPC status error if the response has one.
+func (r MockResponse) Err(resolver dynamic.TypeResolver) error {
+	return r.Error.Err(resolver)
+}
+
+// Err converts the error to a gRPC status error, nil error stays nil.
+// The details types are looked up using the provided resolver.
+func (e *MockResponseError) Err(resolver dynamic.TypeResolver) error {
+	if e == nil {
+		return nil
+	}
+
+	// Create a gRPC status with error details if needed.
+	sts := status.New(codes.Code(e.Code), e.Message) //nolint:gosec // determined range
+	if len(e.Details) == 0 {
+		return sts.Err() //nolint:wrapcheck // plain gRPC error
+	}
+
+	stsProto := sts.Proto()
+
+	for _, detail := range e.Details {
+		anyDetail, err := dynamic.MapToAny(resolver, detail.Type, detail.Body)
+		if err != nil {
+			return fmt.Errorf("encode error detail: %w", err)
+		}
+
+		stsProto.Details = append(stsProto.Details, anyDetail)
+	}
+
+	return status.FromProto(stsProto).Err() //nolint:wrapcheck // plain gRPC error
+}
+
+// MD converts the metadata to the gRPC one, values of the binary keys (ending with -bin)
+// are expected to be base64 encoded.
+func (m MockResponseMetadata) MD() (metadata.MD, error) {
+	md := make(metadata.MD, len(m))
+
+	for key, value := range m {
+		key = strings.ToLower(key)
+
+		if strings.HasSuffix(key, _binaryMetadataSuffix) {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("decode binary value of %s: %w", key, err)
+			}
+
+			value = xstrings.ByteSliceToString(decoded)
+		}
+
+		md.Append(key, value)
+	}
+
+	return md, nil
+}
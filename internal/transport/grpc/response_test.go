@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestMockResponseErrorErr(t *testing.T) {
+	t.Parallel()
+
+	badRequest := &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "Name is invalid"}},
+	}
+
+	tests := []struct {
+		name        string
+		err         *MockResponseError
+		wantCode    codes.Code
+		wantDetails []proto.Message
+		wantErr     bool // The details can't be encoded.
+	}{
+		{name: "no error", err: nil, wantCode: codes.OK, wantDetails: nil, wantErr: false},
+		{name: "no details", err: &MockResponseError{Code: 5, Message: "not found", Details: nil}, wantCode: codes.NotFound, wantDetails: nil, wantErr: false}, //nolint:lll
+		{
+			name: "known detail",
+			err: &MockResponseError{Code: 3, Message: "invalid", Details: []MockResponseErrorDetail{{
+				Type: "google.rpc.BadRequest",
+				Body: MockResponseBody{"fieldViolations": []any{map[string]any{"field": "name", "description": "Name is invalid"}}},
+			}}},
+			wantCode:    codes.InvalidArgument,
+			wantDetails: []proto.Message{badRequest},
+			wantErr:     false,
+		},
+		{
+			name:        "unknown type",
+			err:         &MockResponseError{Code: 3, Message: "invalid", Details: []MockResponseErrorDetail{{Type: "unknown.Detail", Body: nil}}}, //nolint:lll
+			wantCode:    codes.Unknown,
+			wantDetails: nil,
+			wantErr:     true,
+		},
+		{
+			name: "unknown field",
+			err: &MockResponseError{Code: 3, Message: "invalid", Details: []MockResponseErrorDetail{{
+				Type: "google.rpc.BadRequest",
+				Body: MockResponseBody{"unknown": 1},
+			}}},
+			wantCode:    codes.Unknown,
+			wantDetails: nil,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.err.Err(protoregistry.GlobalTypes)
+
+			// An encoding failure is a plain error rather than the status of the mock.
+			sts, isStatus := status.FromError(err)
+			if isStatus == tt.wantErr {
+				t.Fatalf("Err() = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if sts.Code() != tt.wantCode {
+				t.Errorf("Err() code = %s, want %s", sts.Code(), tt.wantCode)
+			}
+
+			details := sts.Details()
+			if len(details) != len(tt.wantDetails) {
+				t.Fatalf("Err() details = %v, want %v", details, tt.wantDetails)
+			}
+
+			for i, detail := range details {
+				msg, ok := detail.(proto.Message)
+				if !ok || !proto.Equal(msg, tt.wantDetails[i]) {
+					t.Errorf("Err() detail %d = %v, want %v", i, detail, tt.wantDetails[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMockResponseMetadataMD(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		metadata MockResponseMetadata
+		want     metadata.MD
+		wantErr  bool
+	}{
+		{name: "empty", metadata: nil, want: metadata.MD{}, wantErr: false},
+		{name: "text value", metadata: MockResponseMetadata{"X-Version": "1.0.0"}, want: metadata.MD{"x-version": {"1.0.0"}}, wantErr: false},                   //nolint:lll
+		{name: "binary value", metadata: MockResponseMetadata{"x-checksum-bin": "AQID"}, want: metadata.MD{"x-checksum-bin": {"\x01\x02\x03"}}, wantErr: false}, //nolint:lll
+		{name: "invalid base64", metadata: MockResponseMetadata{"x-checksum-bin": "not base64!"}, want: nil, wantErr: true},
+		{name: "base64 of text key", metadata: MockResponseMetadata{"x-checksum": "AQID"}, want: metadata.MD{"x-checksum": {"AQID"}}, wantErr: false}, //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.metadata.MD()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MD() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("MD() = %v, want %v", got, tt.want)
+			}
+
+			for key, values := range tt.want {
+				if got := got.Get(key); len(got) != 1 || got[0] != values[0] {
+					t.Errorf("MD() %s = %q, want %q", key, got, values)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/sknv/protomock/pkg/delay"
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/protobuf/dynamic"
+)
+
+var errStreamClosed = errors.New("stream is closed")
+
+// MockStream is exposed to scripts as a `stream` object to send messages on demand.
+type MockStream struct {
+	stream   grpc.ServerStream
+	ctx      context.Context //nolint:containedctx // the delays wait on, see guard
+	output   protoreflect.MessageDescriptor
+	resolver dynamic.TypeResolver
+	sent     []MockResponseBody
+	closed   bool
+	err      error
+}
+
+func NewMockStream(
+	stream grpc.ServerStream,
+	output protoreflect.MessageDescriptor,
+	resolver dynamic.TypeResolver,
+) *MockStream {
+	return &MockStream{
+		stream:   stream,
+		ctx:      stream.Context(),
+		output:   output,
+		resolver: resolver,
+		sent:     nil,
+		closed:   false,
+		err:      nil,
+	}
+}
+
+// Send sends a message to the client after an optional delay, see delay.Parse.
+func (s *MockStream) Send(body MockResponseBody, delay any) error {
+	return s.send(body, delay, fault.Fault{}) //nolint:exhaustruct // no fault
+}
+
+// send sends a message broken according to the body fault if any.
+func (s *MockStream) send(body MockResponseBody, delay any, flt fault.Fault) error {
+	if s.closed {
+		return errStreamClosed
+	}
+
+	if err := wait(s.ctx, delay); err != nil {
+		return err
+	}
+
+	message, err := dynamic.MapToMessage(s.output, body)
+	if err != nil {
+		return fmt.Errorf("encode proto body: %w", err)
+	}
+
+	if err = sendMessage(s.stream, flt, message); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	s.sent = append(s.sent, body)
+
+	return nil
+}
+
+// guard calls the function running scripts by js.Guard, the messages sent meanwhile wait for their delays
+// on the guarded context, so that a delay ends once the script is interrupted or the stream is done.
+func (s *MockStream) guard(ctx context.Context, vm *goja.Runtime, timeout time.Duration, call func() error) error {
+	return js.Guard(ctx, vm, timeout, func(ctx context.Context) error { //nolint:wrapcheck // proxy
+		s.ctx = ctx
+		defer func() { s.ctx = s.stream.Context() }()
+
+		return call()
+	})
+}
+
+// Sent returns the bodies of the messages sent to the client.
+func (s *MockStream) Sent() []MockResponseBody {
+	return s.sent
+}
+
+// SetHeader sets the response headers, they are sent with the first message at the latest.
+func (s *MockStream) SetHeader(headers MockResponseMetadata) error {
+	md, err := headers.MD()
+	if err != nil {
+		return fmt.Errorf("encode headers: %w", err)
+	}
+
+	if err = s.stream.SetHeader(md); err != nil {
+		return fmt.Errorf("set headers: %w", err)
+	}
+
+	return nil
+}
+
+// SetTrailer sets the response trailers sent when the stream is finished.
+func (s *MockStream) SetTrailer(trailers MockResponseMetadata) error {
+	md, err := trailers.MD()
+	if err != nil {
+		return fmt.Errorf("encode trailers: %w", err)
+	}
+
+	s.stream.SetTrailer(md)
+
+	return nil
+}
+
+// Close finishes the stream with an optional error, the following messages are not sent anymore.
+func (s *MockStream) Close(respErr *MockResponseError) {
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	s.err = respErr.Err(s.resolver)
+}
+
+// Closed reports whether the stream has been closed by the script.
+func (s *MockStream) Closed() bool {
+	return s.closed
+}
+
+// Err returns the error the stream has been closed with.
+func (s *MockStream) Err() error {
+	return s.err
+}
+
+// wait pauses the current goroutine for the delay or until the context is done.
+func wait(ctx context.Context, value any) error {
+	pause, err := delay.Parse(value)
+	if err != nil {
+		return fmt.Errorf("parse delay: %w", err)
+	}
+
+	if err = pause.Sleep(ctx); err != nil {
+		return status.FromContextError(err).Err() //nolint:wrapcheck // plain gRPC error
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/sknv/protomock/pkg/grpc/codec"
+)
+
+//nolint:gochecknoglobals // constants
+var (
+	_skippedForwardMetadata = []string{"content-type", "user-agent", "te"}
+
+	// _proxyStreamDesc allows to proxy any kind of methods.
+	_proxyStreamDesc = &grpc.StreamDesc{ //nolint:exhaustruct // only streaming flags
+		ServerStreams: true,
+		ClientStreams: true,
+	}
+)
+
+// Upstream forwards calls to a real service.
+type Upstream struct {
+	conn *grpc.ClientConn
+}
+
+func NewUpstream(target string) (*Upstream, error) {
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("create upstream client: %w", err)
+	}
+
+	return &Upstream{
+		conn: conn,
+	}, nil
+}
+
+// Close closes the upstream connection.
+func (u *Upstream) Close() error {
+	return u.conn.Close() //nolint:wrapcheck // proxy
+}
+
+// UpstreamResponse is a result of the upstream call, either the body or the error is set.
+type UpstreamResponse struct {
+	Headers  metadata.MD
+	Trailers metadata.MD
+	Body     *dynamicpb.Message
+	Err      error // gRPC status error.
+}
+
+// Invoke calls a unary method of the upstream passing the incoming metadata along.
+func (u *Upstream) Invoke(
+	ctx context.Context,
+	fullMethod string,
+	method protoreflect.MethodDescriptor,
+	request *dynamicpb.Message,
+) UpstreamResponse {
+	var (
+		response = dynamicpb.NewMessage(method.Output())
+		headers  metadata.MD
+		trailers metadata.MD
+	)
+
+	err := u.conn.Invoke(
+		forwardMetadata(ctx), fullMethod, request, response,
+		grpc.Header(&headers), grpc.Trailer(&trailers),
+	)
+	if err != nil {
+		response = nil
+	}
+
+	return UpstreamResponse{
+		Headers:  withoutReservedMetadata(headers),
+		Trailers: withoutReservedMetadata(trailers),
+		Body:     response,
+		Err:      err,
+	}
+}
+
+// Proxy passes all the messages of a call between the client and the upstream as raw bytes,
+// so it works for any method including the ones not described by the loaded protos.
+// The server must use codec.RawCodec to receive the raw messages.
+func (u *Upstream) Proxy(stream grpc.ServerStream, fullMethod string) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	client, err := u.conn.NewStream(
+		forwardMetadata(ctx), _proxyStreamDesc, fullMethod,
+		grpc.ForceCodecV2(codec.NewRawCodec()),
+	)
+	if err != nil {
+		return err //nolint:wrapcheck // plain gRPC error
+	}
+
+	// Pass the client messages to the upstream.
+	go func() {
+		for {
+			frame := new(codec.Frame)
+
+			if err := stream.RecvMsg(frame); err != nil {
+				if errors.Is(err, io.EOF) {
+					_ = client.CloseSend()
+				} else {
+					cancel() // The client has gone.
+				}
+
+				return
+			}
+
+			if err := client.SendMsg(frame); err != nil {
+				return // The upstream has finished, its status is received below.
+			}
+		}
+	}()
+
+	// Pass the upstream messages to the client.
+	if header, err := client.Header(); err == nil {
+		if err = stream.SendHeader(withoutReservedMetadata(header)); err != nil {
+			return err //nolint:wrapcheck // plain gRPC error
+		}
+	}
+
+	for {
+		frame := new(codec.Frame)
+
+		err = client.RecvMsg(frame)
+		if err != nil {
+			stream.SetTrailer(withoutReservedMetadata(client.Trailer()))
+
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err //nolint:wrapcheck // plain gRPC error
+		}
+
+		if err = stream.SendMsg(frame); err != nil {
+			return err //nolint:wrapcheck // plain gRPC error
+		}
+	}
+}
+
+// forwardMetadata passes the incoming metadata as the outgoing one except the transport specific keys.
+func forwardMetadata(ctx context.Context) context.Context {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	outgoing := make(metadata.MD, len(incoming))
+
+	for key, values := range incoming {
+		if !slices.Contains(_skippedForwardMetadata, key) {
+			outgoing[key] = values
+		}
+	}
+
+	return metadata.NewOutgoingContext(ctx, withoutReservedMetadata(outgoing))
+}
+
+// withoutReservedMetadata removes the pseudo headers and the keys set by gRPC, e.g. grpc-status-details-bin.
+func withoutReservedMetadata(md metadata.MD) metadata.MD {
+	result := make(metadata.MD, len(md))
+
+	for key, values := range md {
+		if !strings.HasPrefix(key, ":") && !strings.HasPrefix(key, "grpc-") && key != "content-type" {
+			result[key] = values
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/sknv/protomock/pkg/delay"
+	"github.com/sknv/protomock/pkg/fault"
+)
+
+// renderFault breaks the response on purpose, either dropping the connection or corrupting the body.
+func renderFault(w http.ResponseWriter, r *http.Request, response MockResponse, flt fault.Fault) error {
+	if flt.Type == fault.TypeStreamReset {
+		abortStream() // Closes an HTTP/1 connection.
+	}
+
+	if flt.IsConnection() {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if errors.Is(err, http.ErrNotSupported) {
+			abortStream()
+		}
+
+		if err != nil {
+			return fmt.Errorf("hijack connection: %w", err)
+		}
+
+		return flt.Break(conn) //nolint:wrapcheck // proxy
+	}
+
+	// Render the response aside to break it afterwards.
+	buffered := newBufferedWriter()
+	if err := response.Render(buffered); err != nil {
+		return err
+	}
+
+	switch flt.Type {
+	case fault.TypeTruncatedBody:
+		return renderTruncated(w, buffered, flt)
+	case fault.TypeSlowDrip:
+		return renderSlowDrip(w, r, buffered, flt)
+	default:
+		maps.Copy(w.Header(), buffered.header)
+		w.WriteHeader(buffered.status)
+
+		_, err := w.Write(flt.Corrupt(buffered.body.Bytes()))
+
+		return err //nolint:wrapcheck // proxy
+	}
+}
+
+// renderTruncated announces the full body length but sends a part of the body and closes the connection.
+func renderTruncated(w http.ResponseWriter, buffered *bufferedWriter, flt fault.Fault) error {
+	controller := http.NewResponseController(w)
+	body := buffered.body.Bytes()
+
+	conn, rw, err := controller.Hijack()
+	if errors.Is(err, http.ErrNotSupported) {
+		maps.Copy(w.Header(), buffered.header)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buffered.status)
+		_, _ = w.Write(flt.Corrupt(body))
+		_ = controller.Flush()
+
+		abortStream()
+	}
+
+	if err != nil {
+		return fmt.Errorf("hijack connection: %w", err)
+	}
+	defer conn.Close()
+
+	header := w.Header().Clone() // Keep the headers set by the middlewares, e.g. the request id.
+	maps.Copy(header, buffered.header)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	if err = writeRawResponse(rw.Writer, buffered.status, header, flt.Corrupt(body)); err != nil {
+		return fmt.Errorf("write truncated response: %w", err)
+	}
+
+	return nil
+}
+
+// renderSlowDrip sends the body in chunks with pauses in between until the request is canceled.
+func renderSlowDrip(w http.ResponseWriter, r *http.Request, buffered *bufferedWriter, flt fault.Fault) error {
+	ctx := r.Context()
+	controller := http.NewResponseController(w)
+
+	body := buffered.body.Bytes()
+	header := w.Header()
+	maps.Copy(header, buffered.header)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(buffered.status)
+
+	for i, chunk := range flt.Chunks(body) {
+		if i > 0 {
+			if err := delay.Sleep(ctx, flt.PauseDuration()); err != nil {
+				return nil //nolint:nilerr // the client is gone
+			}
+		}
+
+		if _, err := w.Write(chunk); err != nil {
+			return err //nolint:wrapcheck // proxy
+		}
+
+		if err := controller.Flush(); err != nil {
+			return fmt.Errorf("flush chunk: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// abortStream resets the HTTP/2 stream, the connection is shared by other streams so it can't be taken over.
+// An HTTP/1 connection is closed instead.
+func abortStream() {
+	panic(http.ErrAbortHandler)
+}
+
+func writeRawResponse(w *bufio.Writer, status int, header http.Header, body []byte) error {
+	if _, err := fmt.Fprintf(w, "HTTP/1.1 %d %s\r\n", status, http.StatusText(status)); err != nil {
+		return err //nolint:wrapcheck // proxy
+	}
+
+	if err := header.Write(w); err != nil {
+		return err //nolint:wrapcheck // proxy
+	}
+
+	if _, err := w.WriteString("\r\n"); err != nil {
+		return err //nolint:wrapcheck // proxy
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return err //nolint:wrapcheck // proxy
+	}
+
+	return w.Flush() //nolint:wrapcheck // proxy
+}
+
+// ----------------------------------------------------------------------------
+
+// bufferedWriter keeps the whole response in memory.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedWriter() *bufferedWriter {
+	return &bufferedWriter{
+		header: make(http.Header),
+		status: http.StatusOK,
+		body:   bytes.Buffer{},
+	}
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedWriter) Write(data []byte) (int, error) {
+	return b.body.Write(data) //nolint:wrapcheck // proxy
+}
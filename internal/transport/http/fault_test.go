@@ -0,0 +1,155 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sknv/protomock/pkg/fault"
+)
+
+const _faultBody = "Hello, John!"
+
+func TestRenderFault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fault    string
+		http2    bool
+		wantBody string // Compared if there is no error.
+		wantErr  bool   // Either the request or the body read fails.
+	}{
+		{name: "no fault", fault: `null`, http2: false, wantBody: _faultBody, wantErr: false},
+		{name: "connection reset", fault: `"connection_reset"`, http2: false, wantBody: "", wantErr: true},
+		{name: "empty reply", fault: `"empty_reply"`, http2: false, wantBody: "", wantErr: true},
+		{name: "stream reset", fault: `"stream_reset"`, http2: false, wantBody: "", wantErr: true},
+		{name: "truncated body", fault: `"truncated_body"`, http2: false, wantBody: "", wantErr: true},
+		{name: "malformed body", fault: `"malformed_body"`, http2: false, wantBody: "Hello,\xff\xff\xff\xff", wantErr: false},
+		{name: "http2 connection reset", fault: `"connection_reset"`, http2: true, wantBody: "", wantErr: true},
+		{name: "http2 empty reply", fault: `"empty_reply"`, http2: true, wantBody: "", wantErr: true},
+		{name: "http2 stream reset", fault: `"stream_reset"`, http2: true, wantBody: "", wantErr: true},
+		{name: "http2 truncated body", fault: `"truncated_body"`, http2: true, wantBody: "", wantErr: true},
+		{name: "http2 malformed body", fault: `"malformed_body"`, http2: true, wantBody: "Hello,\xff\xff\xff\xff", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := newTestRouter(t, map[string]string{
+				"hello/GET.js": `({ body: "` + _faultBody + `", fault: ` + tt.fault + ` })`,
+			}, nil, 0)
+
+			server := httptest.NewUnstartedServer(router)
+			if tt.http2 {
+				server.EnableHTTP2 = true
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+
+			t.Cleanup(server.Close)
+
+			got, err := get(t, server.Client(), server.URL+"/hello")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && got != tt.wantBody {
+				t.Errorf("get() = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRenderFaultDefault(t *testing.T) {
+	t.Parallel()
+
+	server := startTestServer(t, map[string]string{
+		"hello/GET.js": `({ body: "` + _faultBody + `" })`,
+	}, map[string]fault.Fault{"GET /hello": {Type: fault.TypeEmptyReply}}, 0) //nolint:exhaustruct
+
+	if _, err := get(t, server.Client(), server.URL+"/hello"); err == nil {
+		t.Errorf("get() error = nil, want the connection closed")
+	}
+}
+
+func TestRenderSlowDrip(t *testing.T) {
+	t.Parallel()
+
+	const interval = 50 * time.Millisecond
+
+	server := startTestServer(t, map[string]string{
+		"hello/GET.js": `({ body: "` + _faultBody + `", fault: { type: "slow_drip", chunkSize: 4, interval: 50 } })`,
+	}, nil, 0)
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/hello", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	start := time.Now()
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer response.Body.Close()
+
+	// The first chunk comes before the rest of the body.
+	first := make([]byte, len(_faultBody))
+
+	n, err := response.Body.Read(first)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if n != 4 {
+		t.Errorf("first chunk is %q, want 4 bytes", first[:n])
+	}
+
+	rest, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	if got := string(first[:n]) + string(rest); got != _faultBody {
+		t.Errorf("body = %q, want %q", got, _faultBody)
+	}
+
+	// 3 chunks with 2 pauses in between.
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("body is sent in %s, want at least %s", elapsed, 2*interval)
+	}
+}
+
+// get requests the URL returning the body, an error is returned if either the request or the body read fails.
+func get(t *testing.T, client *http.Client, url string) (string, error) {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err //nolint:wrapcheck // checked by the tests
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err //nolint:wrapcheck // checked by the tests
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New(response.Status) //nolint:err113 // test
+	}
+
+	return string(body), nil
+}
@@ -1,42 +1,272 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/uptrace/bunrouter"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/http/middleware"
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/log"
+	"github.com/sknv/protomock/pkg/option"
 )
 
+//nolint:gochecknoglobals // constants
+var _routerMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
 type Handlers struct {
-	mocks Mocks
+	journal  option.Option[*journal.Journal] // Records the handled requests if set.
+	runtimes *js.Pool                        // Runtimes with the globals shared by every mock, e.g. the store.
+	upstream option.Option[*Upstream]        // Serves the unmatched requests if set.
+	recorder option.Option[*Recorder]        // Records the upstream responses if set.
+	faults   map[string]fault.Fault          // Default faults by routes, e.g. GET /users/:user_id.
+	timeouts js.Timeouts                     // Script timeouts by routes.
+	routes   atomic.Pointer[routes]          // Swapped on reload.
 }
 
-func NewHandlers(mocks Mocks) *Handlers {
-	return &Handlers{
-		mocks: mocks,
+func NewHandlers(
+	mocks Mocks,
+	jrnl option.Option[*journal.Journal],
+	runtimes *js.Pool,
+	upstream option.Option[*Upstream],
+	recorder option.Option[*Recorder],
+	faults map[string]fault.Fault,
+	timeouts js.Timeouts,
+) (*Handlers, error) {
+	handlers := &Handlers{
+		journal:  jrnl,
+		runtimes: runtimes,
+		upstream: upstream,
+		recorder: recorder,
+		faults:   faults,
+		timeouts: timeouts,
+		routes:   atomic.Pointer[routes]{},
+	}
+
+	if err := handlers.Reload(mocks); err != nil {
+		return nil, err
 	}
+
+	return handlers, nil
 }
 
+// Route passes all the requests to the current mocks router.
 func (h *Handlers) Route(router *bunrouter.Router) {
-	for _, mock := range h.mocks {
-		handleMockRequest(router, mock)
+	for _, method := range _routerMethods {
+		router.Handle(method, "/*path", h.serve)
 	}
 }
 
-func handleMockRequest(router *bunrouter.Router, mock Mock) {
+// Reload builds a new mocks router and atomically swaps the current one with it.
+func (h *Handlers) Reload(mocks Mocks) error {
+	router, err := h.newMocksRouter(mocks)
+	if err != nil {
+		return fmt.Errorf("build mocks router: %w", err)
+	}
+
+	h.routes.Store(&routes{
+		mocks:  mocks,
+		router: router,
+	})
+
+	return nil
+}
+
+// Mocks returns the currently served mocks.
+func (h *Handlers) Mocks() Mocks {
+	return h.routes.Load().mocks
+}
+
+func (h *Handlers) serve(w http.ResponseWriter, r bunrouter.Request) error {
+	return h.routes.Load().router.ServeHTTPError(w, r.Request) //nolint:wrapcheck // proxy
+}
+
+// ----------------------------------------------------------------------------
+
+type routes struct {
+	mocks  Mocks
+	router *bunrouter.Router
+}
+
+//nolint:nonamedreturns // used in defer
+func (h *Handlers) newMocksRouter(mocks Mocks) (router *bunrouter.Router, err error) {
+	// The router panics on conflicting routes.
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			router, err = nil, fmt.Errorf("register routes: %v", rvr) //nolint:err113 // dynamic error
+		}
+	}()
+
+	router = bunrouter.New(
+		bunrouter.WithNotFoundHandler(h.handleNotFound),
+		bunrouter.WithMethodNotAllowedHandler(h.handleMethodNotAllowed),
+	)
+	for _, mock := range mocks {
+		h.handleMockRequest(router, mock)
+	}
+
+	return router, nil
+}
+
+func (h *Handlers) handleMockRequest(router *bunrouter.Router, mock Mock) {
 	router.Handle(mock.Method, mock.Path, func(w http.ResponseWriter, r bunrouter.Request) error {
 		ctx := r.Context()
+		start := time.Now()
 
 		request, err := NewMockRequestFrom(r)
 		if err != nil {
+			h.record(r, start, http.StatusInternalServerError, nil, nil)
+
 			return fmt.Errorf("decode request: %w", err)
 		}
 
-		response, err := mock.Eval(ctx, request)
+		response, err := mock.Eval(ctx, h.runtimes, h.timeouts.Get(mock.Route()), request)
+		if isTimeout(err) {
+			h.record(r, start, http.StatusGatewayTimeout, request, nil)
+			log.FromContext(ctx).WarnContext(ctx, "Mock script timed out", slog.Any("error", err))
+			http.Error(w, err.Error(), http.StatusGatewayTimeout)
+
+			return nil
+		}
+
 		if err != nil {
+			h.record(r, start, http.StatusInternalServerError, request, nil)
+
 			return fmt.Errorf("evaluate mock: %w", err)
 		}
 
-		return response.JSON(w)
+		if err = response.Wait(ctx); err != nil {
+			h.record(r, start, http.StatusInternalServerError, request, response)
+
+			return fmt.Errorf("delay response: %w", err)
+		}
+
+		flt, err := h.fault(mock, response)
+		if err != nil {
+			h.record(r, start, http.StatusInternalServerError, request, response)
+
+			return fmt.Errorf("parse fault: %w", err)
+		}
+
+		h.record(r, start, response.StatusCode(), request, response)
+
+		if !flt.IsNone() {
+			return renderFault(w, r.Request, response, flt)
+		}
+
+		return response.Render(w)
 	})
 }
+
+// fault returns the fault provided by the script or the default one of the mock route.
+func (h *Handlers) fault(mock Mock, response MockResponse) (fault.Fault, error) {
+	if response.Fault != nil {
+		return fault.Parse(response.Fault) //nolint:wrapcheck // proxy
+	}
+
+	return h.faults[mock.Route()], nil
+}
+
+// isTimeout reports whether a script has been interrupted due to either its own timeout or the request deadline.
+func isTimeout(err error) bool {
+	return errors.Is(err, js.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (h *Handlers) handleNotFound(w http.ResponseWriter, r bunrouter.Request) error {
+	return h.handleUnmatched(w, r, http.StatusNotFound)
+}
+
+func (h *Handlers) handleMethodNotAllowed(w http.ResponseWriter, r bunrouter.Request) error {
+	return h.handleUnmatched(w, r, http.StatusMethodNotAllowed)
+}
+
+// handleUnmatched passes the request to the upstream if there is one, recording the response in the record mode.
+// Responds with the status otherwise.
+func (h *Handlers) handleUnmatched(w http.ResponseWriter, r bunrouter.Request, status int) error {
+	start := time.Now()
+
+	if h.upstream.IsNone() {
+		h.record(r, start, status, nil, nil)
+		http.Error(w, http.StatusText(status), status)
+
+		return nil
+	}
+
+	upstream := h.upstream.Unwrap()
+
+	if h.recorder.IsNone() {
+		status = upstream.Proxy(w, r.Request)
+		h.record(r, start, status, nil, nil)
+
+		return nil
+	}
+
+	response, err := upstream.Forward(r.Request)
+	if err != nil {
+		h.record(r, start, http.StatusBadGateway, nil, nil)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+
+		return nil
+	}
+
+	h.record(r, start, response.Status, nil, nil)
+	recordMock(r, h.recorder.Unwrap(), response)
+
+	return response.Render(w)
+}
+
+// recordMock saves the upstream response as a script of the request method and path,
+// a failure is only logged, so that the request is still proxied.
+func recordMock(r bunrouter.Request, recorder *Recorder, response UpstreamResponse) {
+	ctx := r.Context()
+	logger := log.FromContext(ctx)
+
+	file, err := recorder.Record(r.Request, response)
+	if err != nil {
+		logger.WarnContext(ctx, "Can't record http mock", slog.Any("error", err))
+
+		return
+	}
+
+	if file != "" {
+		logger.InfoContext(ctx, "Http mock recorded", slog.String("file", file))
+	}
+}
+
+// record adds the request to the journal if there is one.
+func (h *Handlers) record(r bunrouter.Request, start time.Time, status int, request, response any) {
+	if h.journal.IsSome() {
+		h.journal.Unwrap().Record(newJournalEntry(r, start, status, request, response))
+	}
+}
+
+func newJournalEntry(r bunrouter.Request, start time.Time, status int, request, response any) journal.Entry {
+	return journal.Entry{
+		Time:      start,
+		Protocol:  journal.ProtocolHTTP,
+		Method:    r.Method,
+		Route:     r.Route(),
+		Path:      r.URL.Path,
+		Status:    status,
+		LatencyMs: time.Since(start).Milliseconds(),
+		RequestID: middleware.GetRequestID(r.Context()),
+		Request:   request,
+		Response:  response,
+	}
+}
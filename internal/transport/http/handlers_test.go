@@ -0,0 +1,140 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uptrace/bunrouter"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/pkg/fault"
+	"github.com/sknv/protomock/pkg/http/middleware"
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/option"
+)
+
+// newTestRouter serves the mock files by their paths relative to the mocks dir, e.g. users/GET.js.
+func newTestRouter(
+	t *testing.T, files map[string]string, faults map[string]fault.Fault, timeout time.Duration,
+) *bunrouter.Router {
+	t.Helper()
+
+	mocksDir := t.TempDir()
+	for name, content := range files {
+		writeFile(t, filepath.Join(mocksDir, name), content)
+	}
+
+	mocks, err := BuildMocks(mocksDir, "")
+	if err != nil {
+		t.Fatalf("BuildMocks() error = %v", err)
+	}
+
+	handlers, err := NewHandlers(
+		mocks, option.Some(journal.New(100)), js.NewPool(1, 0, nil), option.None[*Upstream](), option.None[*Recorder](),
+		faults, js.Timeouts{Default: timeout, ByKey: nil},
+	)
+	if err != nil {
+		t.Fatalf("NewHandlers() error = %v", err)
+	}
+
+	router := bunrouter.New(bunrouter.Use(middleware.HandleError))
+	handlers.Route(router)
+
+	return router
+}
+
+// startTestServer serves the mock files over HTTP/1.1.
+func startTestServer(
+	t *testing.T, files map[string]string, faults map[string]fault.Fault, timeout time.Duration,
+) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(newTestRouter(t, files, faults, timeout))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func writeFile(tb testing.TB, path, content string) {
+	tb.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		tb.Fatalf("create dir error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		tb.Fatalf("write file error = %v", err)
+	}
+}
+
+func TestHandlersReload(t *testing.T) {
+	t.Parallel()
+
+	mocksDir := t.TempDir()
+	writeFile(t, filepath.Join(mocksDir, "lib", "greeting.js"), `module.exports = (name) => "Hello, " + name`)
+	writeFile(t, filepath.Join(mocksDir, "hello", "GET.js"), `({ body: require("greeting")("John") })`)
+
+	mocks, err := BuildMocks(mocksDir, "")
+	if err != nil {
+		t.Fatalf("BuildMocks() error = %v", err)
+	}
+
+	// The requests are not journaled without the admin server.
+	handlers, err := NewHandlers(
+		mocks, option.None[*journal.Journal](), js.NewPool(1, 0, nil), option.None[*Upstream](), option.None[*Recorder](),
+		nil, js.Timeouts{Default: 0, ByKey: nil},
+	)
+	if err != nil {
+		t.Fatalf("NewHandlers() error = %v", err)
+	}
+
+	router := bunrouter.New(bunrouter.Use(middleware.HandleError))
+	handlers.Route(router)
+
+	// The steps share the mocks dir and run in order, a failed build keeps the previous mocks like the watcher does.
+	steps := []struct {
+		name     string
+		file     string
+		content  string
+		path     string
+		wantErr  bool
+		wantBody string
+	}{
+		{name: "initial", file: "", content: "", path: "/hello", wantErr: false, wantBody: "Hello, John"},
+		{name: "lib module change", file: "lib/greeting.js", content: `module.exports = (name) => "Hi, " + name`, path: "/hello", wantErr: false, wantBody: "Hi, John"}, //nolint:lll
+		{name: "mock change", file: "hello/GET.js", content: `({ body: require("greeting")("Jane") })`, path: "/hello", wantErr: false, wantBody: "Hi, Jane"},           //nolint:lll
+		{name: "new mock", file: "bye/GET.js", content: `({ body: "Bye" })`, path: "/bye", wantErr: false, wantBody: "Bye"},                                             //nolint:lll
+		{name: "broken mock", file: "hello/GET.js", content: `({ body: `, path: "/hello", wantErr: true, wantBody: "Hi, Jane"},                                          //nolint:lll
+		{name: "broken lib module", file: "lib/greeting.js", content: `module.exports = (`, path: "/hello", wantErr: true, wantBody: "Hi, Jane"},                        //nolint:lll
+	}
+
+	for _, step := range steps {
+		if step.file != "" {
+			writeFile(t, filepath.Join(mocksDir, step.file), step.content)
+		}
+
+		mocks, err := BuildMocks(mocksDir, "")
+		if err == nil {
+			err = handlers.Reload(mocks)
+		}
+
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: reload error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, step.path, nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: GET %s status = %d, want %d", step.name, step.path, recorder.Code, http.StatusOK)
+		}
+
+		if recorder.Body.String() != step.wantBody {
+			t.Errorf("%s: GET %s body = %q, want %q", step.name, step.path, recorder.Body, step.wantBody)
+		}
+	}
+}
@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/dop251/goja"
+
+	"github.com/sknv/protomock/pkg/document"
 	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/option"
 	xstrings "github.com/sknv/protomock/pkg/strings"
 )
 
@@ -19,28 +25,45 @@ const (
 )
 
 type Mock struct {
-	Method string
-	Path   string
-	Script string
+	Method   string
+	Path     string
+	File     string                      // Script or static response file path.
+	Program  *goja.Program               // Compiled script, nil for a static response.
+	Response option.Option[MockResponse] // Static response of a JSON or YAML file.
+	Modules  *js.Modules                 // Modules the script may require.
 }
 
 type Mocks []Mock
 
-func (m Mock) Eval(ctx context.Context, request MockRequest) (MockResponse, error) {
-	vm := js.NewRuntime()
+// Route returns the mock route along with the method, e.g. GET /users/:user_id.
+func (m Mock) Route() string {
+	return m.Method + " " + m.Path
+}
 
-	console := js.NewConsole(ctx)
-	if err := vm.Set("console", console); err != nil {
-		return MockResponse{}, fmt.Errorf("set console in runtime: %w", err)
+// Eval evaluates the mock script in a pooled runtime providing the request, a static response is returned as is.
+// The script is interrupted once the context is done or the timeout, if any, expires.
+func (m Mock) Eval(
+	ctx context.Context, runtimes *js.Pool, timeout time.Duration, request MockRequest,
+) (MockResponse, error) {
+	if m.Response.IsSome() {
+		return m.Response.Unwrap(), nil
 	}
 
-	if err := vm.Set("request", request); err != nil {
-		return MockResponse{}, fmt.Errorf("set request in runtime: %w", err)
+	vm, err := runtimes.Get()
+	if err != nil {
+		return MockResponse{}, fmt.Errorf("get runtime: %w", err)
 	}
+	defer runtimes.Put(vm)
 
-	eval, err := vm.RunString(m.Script)
+	if err = js.SetCallGlobals(ctx, vm, m.Modules, js.Globals{
+		"request": request,
+	}); err != nil {
+		return MockResponse{}, err
+	}
+
+	eval, err := js.Run(ctx, vm, m.Program, timeout)
 	if err != nil {
-		return MockResponse{}, fmt.Errorf("eval script: %w", err)
+		return MockResponse{}, fmt.Errorf("eval script %s: %w", m.File, err)
 	}
 
 	var response MockResponse
@@ -48,14 +71,26 @@ func (m Mock) Eval(ctx context.Context, request MockRequest) (MockResponse, erro
 		return MockResponse{}, fmt.Errorf("export response from js: %w", err)
 	}
 
+	// An ArrayBuffer is exported as is unlike the typed arrays, so turn it into bytes as well.
+	if buffer, ok := response.Body.(goja.ArrayBuffer); ok {
+		response.Body = buffer.Bytes()
+	}
+
 	return response, nil
 }
 
 // ----------------------------------------------------------------------------
 
-// BuildMocks traverses the directory and populate Mocks.
-func BuildMocks(mocksDir string) (Mocks, error) {
-	var mocks Mocks
+// BuildMocks traverses the directory and populate Mocks skipping the modules of the lib dir,
+// which is the lib inside the mocks dir by default. A mock is either a script, e.g. GET.js,
+// or a static response, e.g. GET.json or GET.yaml. Other JSON and YAML files are skipped.
+//
+//nolint:funlen // mostly basic operations
+func BuildMocks(mocksDir, libDir string) (Mocks, error) {
+	var (
+		mocks   Mocks
+		modules = js.NewModules(mocksDir, libDir)
+	)
 
 	// Walk through the directory
 	err := filepath.Walk(mocksDir, func(path string, info os.FileInfo, err error) error {
@@ -63,13 +98,22 @@ func BuildMocks(mocksDir string) (Mocks, error) {
 			return fmt.Errorf("traverse path: %w", err)
 		}
 
-		// Skip directories.
+		// Skip directories and the shared modules.
 		if info.IsDir() {
+			if modules.IsLib(path) {
+				return filepath.SkipDir
+			}
+
 			return nil
 		}
 
-		// Process only files with the proper extension.
-		if filepath.Ext(path) != _mockFileExtension {
+		// Process only scripts and static responses, a JSON or YAML file is a static response
+		// only if it is named after a method, e.g. GET.json, so that data files are skipped.
+		ext := filepath.Ext(path)
+		httpMethod := strings.TrimSuffix(info.Name(), ext)
+
+		isStatic := document.IsFile(path) && slices.Contains(_routerMethods, httpMethod)
+		if ext != _mockFileExtension && !isStatic {
 			return nil
 		}
 
@@ -79,7 +123,6 @@ func BuildMocks(mocksDir string) (Mocks, error) {
 			return fmt.Errorf("read file: %w", err)
 		}
 
-		httpMethod := strings.TrimSuffix(info.Name(), _mockFileExtension)
 		httpPath := filepath.ToSlash( // Transform OS-specific separators to slashes.
 			strings.TrimPrefix(filepath.Dir(path), filepath.Clean(mocksDir)), // Trim original mocks dir.
 		)
@@ -90,9 +133,27 @@ func BuildMocks(mocksDir string) (Mocks, error) {
 		)
 
 		mock := Mock{
-			Method: httpMethod,
-			Path:   httpPath,
-			Script: xstrings.ByteSliceToString(content),
+			Method:   httpMethod,
+			Path:     httpPath,
+			File:     path,
+			Program:  nil,
+			Response: option.None[MockResponse](),
+			Modules:  modules,
+		}
+
+		if isStatic {
+			response, err := decodeStaticResponse(path, content)
+			if err != nil {
+				return fmt.Errorf("decode static mock %s: %w", path, err)
+			}
+
+			mock.Response = option.Some(response)
+		} else {
+			// Compile the script once, so that syntax errors are reported right away.
+			mock.Program, err = js.Compile(path, xstrings.ByteSliceToString(content))
+			if err != nil {
+				return fmt.Errorf("compile mock: %w", err)
+			}
 		}
 
 		mocks = append(mocks, mock)
@@ -103,5 +164,9 @@ func BuildMocks(mocksDir string) (Mocks, error) {
 		return nil, fmt.Errorf("filepath walk: %w", err)
 	}
 
+	if err = modules.CompileLib(); err != nil {
+		return nil, fmt.Errorf("compile modules: %w", err)
+	}
+
 	return mocks, nil
 }
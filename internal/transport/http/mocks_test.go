@@ -0,0 +1,159 @@
+package http
+
+import (
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/dop251/goja"
+
+	"github.com/sknv/protomock/pkg/js"
+)
+
+func TestBuildMocksStaticResponses(t *testing.T) {
+	t.Parallel()
+
+	mocksDir := t.TempDir()
+	for name, content := range map[string]string{
+		"users/GET.json":     `{"body": [{"id": 1}]}`,
+		"users/POST.yaml":    "status: 201\n",
+		"users/__id/PUT.yml": "status: 204\n",
+		"users.json":         `[{"id": 1, "name": "John"}]`,
+		"users/data.yaml":    "name: John\n",
+		"users/get.json":     `{"unknown": true}`,
+		"users/schema.json":  `{"type": "object"}`,
+	} {
+		writeFile(t, filepath.Join(mocksDir, name), content)
+	}
+
+	mocks, err := BuildMocks(mocksDir, "")
+	if err != nil {
+		t.Fatalf("BuildMocks() error = %v", err)
+	}
+
+	routes := make([]string, 0, len(mocks))
+	for _, mock := range mocks {
+		routes = append(routes, mock.Route())
+	}
+
+	slices.Sort(routes)
+
+	want := []string{"GET /users", "POST /users", "PUT /users/:id"}
+	if !slices.Equal(routes, want) {
+		t.Errorf("BuildMocks() routes = %v, want %v", routes, want)
+	}
+}
+
+// _benchScript is a mock script of a typical size: a helper, a validation and a response built from the request.
+const _benchScript = `const roles = ["admin", "editor", "viewer"];
+
+function buildUser(id, name) {
+  return {
+    id: Number(id),
+    name: name,
+    email: name.toLowerCase().replace(/\s+/g, ".") + "@example.com",
+    role: roles[Number(id) % roles.length],
+    tags: (request.queryValues.tag || []).map((tag) => tag.trim().toLowerCase()),
+    createdAt: new Date(Date.UTC(2024, 0, Number(id))).toISOString(),
+  };
+}
+
+const id = request.params.user_id;
+
+if (!/^\d+$/.test(id)) {
+  ({ status: 400, body: { error: "invalid user id", id: id } });
+} else {
+  const user = buildUser(id, request.query.name || "John");
+  const friends = [1, 2, 3, 4, 5]
+    .filter((n) => String(n) !== id)
+    .map((n) => buildUser(n, "Friend " + n));
+
+  ({
+    status: 200,
+    headers: { "X-Request-Id": request.headers["X-Request-Id"] || "none" },
+    body: { ...user, friends: friends, total: friends.length },
+  });
+}
+`
+
+// BenchmarkEval compares the evaluation of a mock the way it used to be done, i.e. running the source
+// in a new runtime on every request, with running the script compiled once in a new runtime,
+// and with Mock.Eval running the compiled script in a pooled runtime.
+func BenchmarkEval(b *testing.B) {
+	mocksDir := b.TempDir()
+	writeFile(b, filepath.Join(mocksDir, "users", "__user_id", "GET.js"), _benchScript)
+
+	mocks, err := BuildMocks(mocksDir, "")
+	if err != nil {
+		b.Fatalf("BuildMocks() error = %v", err)
+	}
+
+	mock := mocks[0]
+
+	//nolint:exhaustruct // only the fields used by the script
+	request := MockRequest{
+		Params:      MockRequestParams{"user_id": "2"},
+		Query:       MockRequestQuery{"name": "John Doe"},
+		QueryValues: MockRequestQueryValues{"tag": {" Admin ", "VIP"}},
+		Headers:     MockRequestHeaders{"X-Request-Id": "req-1"},
+	}
+
+	// newRuntime creates a runtime the way it used to be done for every request.
+	newRuntime := func(b *testing.B) *goja.Runtime {
+		b.Helper()
+
+		vm := js.NewRuntime()
+		if err := js.SetCallGlobals(b.Context(), vm, mock.Modules, js.Globals{"request": request}); err != nil {
+			b.Fatalf("SetCallGlobals() error = %v", err)
+		}
+
+		return vm
+	}
+
+	export := func(b *testing.B, vm *goja.Runtime, eval goja.Value) {
+		b.Helper()
+
+		var response MockResponse
+		if err := vm.ExportTo(eval, &response); err != nil || response.Status != http.StatusOK {
+			b.Fatalf("ExportTo() = %+v, %v, want status %d", response, err, http.StatusOK)
+		}
+	}
+
+	b.Run("source per request", func(b *testing.B) {
+		for b.Loop() {
+			vm := newRuntime(b)
+
+			eval, err := vm.RunString(_benchScript)
+			if err != nil {
+				b.Fatalf("RunString() error = %v", err)
+			}
+
+			export(b, vm, eval)
+		}
+	})
+
+	b.Run("compiled once", func(b *testing.B) {
+		for b.Loop() {
+			vm := newRuntime(b)
+
+			eval, err := vm.RunProgram(mock.Program)
+			if err != nil {
+				b.Fatalf("RunProgram() error = %v", err)
+			}
+
+			export(b, vm, eval)
+		}
+	})
+
+	b.Run("compiled once and pooled", func(b *testing.B) {
+		runtimes := js.NewPool(1, 0, nil)
+
+		for b.Loop() {
+			response, err := mock.Eval(b.Context(), runtimes, 0, request)
+			if err != nil || response.Status != http.StatusOK {
+				b.Fatalf("Eval() = %+v, %v, want status %d", response, err, http.StatusOK)
+			}
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	_recordedDirMode  = 0o755
+	_recordedFileMode = 0o644
+
+	_recordedIndent = "    " // Indent of the response fields inside the script.
+)
+
+//nolint:gochecknoglobals // constants
+var (
+	// _idSegment matches the path segments recorded as params, i.e. numbers and UUIDs.
+	_idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+	// _skippedRecordHeaders are not recorded as they are either set by the server or by the script fields.
+	_skippedRecordHeaders = []string{"Content-Length", "Content-Type", "Date", "Server"}
+)
+
+var errNotRecordable = errors.New("request is not recordable")
+
+// Recorder writes the observed upstream responses as mocks.
+type Recorder struct {
+	mocksDir string
+}
+
+func NewRecorder(mocksDir string) *Recorder {
+	return &Recorder{
+		mocksDir: mocksDir,
+	}
+}
+
+// Record writes the response as a mock script for the request method and path, path segments looking like ids
+// are turned into params, e.g. /users/42 is recorded as users/__user_id/GET.js.
+// The existing mocks are never overwritten, an empty file path is returned in this case.
+func (r *Recorder) Record(req *http.Request, resp UpstreamResponse) (string, error) {
+	if resp.Status == http.StatusNotFound || resp.Status >= http.StatusInternalServerError {
+		return "", fmt.Errorf("status %d: %w", resp.Status, errNotRecordable)
+	}
+
+	dir, err := recordedMockDir(r.mocksDir, req.URL.EscapedPath())
+	if err != nil {
+		return "", err
+	}
+
+	if !slices.Contains(_routerMethods, req.Method) {
+		return "", fmt.Errorf("method %s: %w", req.Method, errNotRecordable)
+	}
+
+	// Render the script first, so that the file is only created once there is something to write.
+	script := recordedScript(req, resp)
+
+	if err = os.MkdirAll(dir, _recordedDirMode); err != nil {
+		return "", fmt.Errorf("create mock dir: %w", err)
+	}
+
+	filePath := filepath.Join(dir, req.Method+_mockFileExtension)
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, _recordedFileMode)
+	if errors.Is(err, fs.ErrExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("create mock file: %w", err)
+	}
+
+	_, err = file.WriteString(script)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(filePath) // Let the route be recorded next time.
+
+		return "", fmt.Errorf("write mock file: %w", err)
+	}
+
+	return filePath, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// recordedMockDir builds the mock directory of the escaped request path, every segment is unescaped once
+// and must stay a single directory inside the mocks one.
+func recordedMockDir(mocksDir, escapedPath string) (string, error) {
+	var (
+		segments = []string{mocksDir}
+		previous string
+	)
+
+	for _, segment := range strings.Split(escapedPath, "/") {
+		segment, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", fmt.Errorf("unescape path: %w", err)
+		}
+
+		switch {
+		case segment == "":
+			continue
+		case segment == ".", segment == "..",
+			strings.ContainsAny(segment, `/\:*`), strings.ContainsRune(segment, os.PathSeparator):
+			return "", fmt.Errorf("path segment %q: %w", segment, errNotRecordable)
+		case _idSegment.MatchString(segment):
+			segments = append(segments, wildcardPatternToFind+paramName(previous))
+		default:
+			segments = append(segments, segment)
+		}
+
+		previous = segment
+	}
+
+	if len(segments) == 1 {
+		return "", fmt.Errorf("root path: %w", errNotRecordable)
+	}
+
+	dir := filepath.Join(segments...)
+
+	rel, err := filepath.Rel(mocksDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the mocks dir: %w", escapedPath, errNotRecordable)
+	}
+
+	return dir, nil
+}
+
+// paramName names a param after the previous path segment, e.g. user_id for /users/42.
+func paramName(previous string) string {
+	name := strings.TrimSuffix(previous, "s")
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	if name == "" {
+		return "id"
+	}
+
+	return name + "_id"
+}
+
+// recordedScript renders a mock script returning the response.
+func recordedScript(req *http.Request, resp UpstreamResponse) string {
+	fields := []string{"status: " + strconv.Itoa(resp.Status)}
+
+	if headers := recordedHeaders(resp.Header); len(headers) > 0 {
+		fields = append(fields, "headers: "+indentedJSON(headers))
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		fields = append(fields, "contentType: "+indentedJSON(contentType))
+	}
+
+	if len(resp.Body) > 0 {
+		fields = append(fields, "body: "+recordedBody(resp.Header.Get("Content-Type"), resp.Body))
+	}
+
+	var script strings.Builder
+
+	fmt.Fprintf(&script, "// Recorded from %s %s\n", req.Method, req.URL.RequestURI())
+	script.WriteString("(function () {\n  return {\n")
+	script.WriteString(_recordedIndent + strings.Join(fields, ",\n"+_recordedIndent) + "\n")
+	script.WriteString("  }\n})()\n")
+
+	return script.String()
+}
+
+// recordedHeaders converts the headers to the script format, i.e. single values become strings.
+func recordedHeaders(header http.Header) map[string]any {
+	headers := make(map[string]any, len(header))
+
+	for key, values := range header {
+		switch {
+		case slices.Contains(_skippedRecordHeaders, key):
+			continue
+		case len(values) == 1:
+			headers[key] = values[0]
+		default:
+			headers[key] = values
+		}
+	}
+
+	return headers
+}
+
+// recordedBody renders the body as a JSON value, a string or bytes depending on the content.
+func recordedBody(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	if mediaType == _jsonContentType || strings.HasSuffix(mediaType, "+json") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, _recordedIndent, "  "); err == nil {
+			return indented.String()
+		}
+	}
+
+	if utf8.Valid(body) {
+		return indentedJSON(string(body))
+	}
+
+	items := make([]string, 0, len(body))
+	for _, b := range body {
+		items = append(items, strconv.Itoa(int(b)))
+	}
+
+	return "new Uint8Array([" + strings.Join(items, ", ") + "]).buffer"
+}
+
+// indentedJSON renders the value as a JSON literal indented for the response fields.
+func indentedJSON(value any) string {
+	var data bytes.Buffer
+
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(_recordedIndent, "  ")
+
+	if err := encoder.Encode(value); err != nil {
+		return "null"
+	}
+
+	return strings.TrimSuffix(data.String(), "\n")
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordedMockDir(t *testing.T) {
+	t.Parallel()
+
+	mocksDir := filepath.Join("mocks", "http")
+
+	tests := []struct {
+		name        string
+		escapedPath string
+		want        string
+		wantErr     error
+	}{
+		{name: "plain path", escapedPath: "/users/me", want: filepath.Join(mocksDir, "users", "me"), wantErr: nil},
+		{name: "number param", escapedPath: "/users/42", want: filepath.Join(mocksDir, "users", "__user_id"), wantErr: nil},
+		{
+			name:        "uuid param",
+			escapedPath: "/orders/123e4567-e89b-12d3-a456-426614174000/items",
+			want:        filepath.Join(mocksDir, "orders", "__order_id", "items"),
+			wantErr:     nil,
+		},
+		{name: "leading param", escapedPath: "/42", want: filepath.Join(mocksDir, "__id"), wantErr: nil},
+		{name: "empty segments", escapedPath: "//users//me/", want: filepath.Join(mocksDir, "users", "me"), wantErr: nil},
+		{name: "escaped space", escapedPath: "/my%20files", want: filepath.Join(mocksDir, "my files"), wantErr: nil},
+		{name: "root path", escapedPath: "/", want: "", wantErr: errNotRecordable},
+		{name: "dot dot", escapedPath: "/users/../..", want: "", wantErr: errNotRecordable},
+		{name: "escaped dot dot", escapedPath: "/users/%2E%2E", want: "", wantErr: errNotRecordable},
+		{name: "escaped slash", escapedPath: "/a%2F..%2F..%2Fescaped", want: "", wantErr: errNotRecordable},
+		{name: "double escaped slash", escapedPath: "/a%252F..%252F..%252Fescaped", want: filepath.Join(mocksDir, "a%2F..%2F..%2Fescaped"), wantErr: nil}, //nolint:lll
+		{name: "backslash", escapedPath: `/a%5C..%5Cescaped`, want: "", wantErr: errNotRecordable},
+		{name: "wildcard", escapedPath: "/users/*", want: "", wantErr: errNotRecordable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := recordedMockDir(mocksDir, tt.escapedPath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("recordedMockDir() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("recordedMockDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorderSkipsFailedResponses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{name: "ok", status: http.StatusOK, wantErr: nil},
+		{name: "bad request", status: http.StatusBadRequest, wantErr: nil},
+		{name: "not found", status: http.StatusNotFound, wantErr: errNotRecordable},
+		{name: "internal error", status: http.StatusInternalServerError, wantErr: errNotRecordable},
+		{name: "bad gateway", status: http.StatusBadGateway, wantErr: errNotRecordable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := NewRecorder(t.TempDir())
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+
+			file, err := recorder.Record(req, UpstreamResponse{Status: tt.status, Header: http.Header{}, Body: nil})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Record() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if (file != "") != (tt.wantErr == nil) {
+				t.Errorf("Record() file = %q", file)
+			}
+		})
+	}
+}
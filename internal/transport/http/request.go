@@ -1,32 +1,68 @@
 package http
 
 import (
+	"bytes"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
 	"strings"
 
+	"github.com/dop251/goja"
 	"github.com/uptrace/bunrouter"
 
 	"github.com/sknv/protomock/pkg/http/render"
+	xstrings "github.com/sknv/protomock/pkg/strings"
+	xtls "github.com/sknv/protomock/pkg/tls"
 )
 
 type (
-	MockRequestParams  map[string]string
-	MockRequestHeaders map[string]string
-	MockRequestBody    map[string]any
+	MockRequestParams      map[string]string
+	MockRequestQuery       map[string]string
+	MockRequestQueryValues map[string][]string
+	MockRequestHeaders     map[string]string
+	MockRequestBody        any
 )
 
+type MockRequestFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
 type MockRequest struct {
-	Params  MockRequestParams  `json:"params"`
-	Headers MockRequestHeaders `json:"headers"`
-	Body    MockRequestBody    `json:"body"`
+	Method      string                 `json:"method"`
+	Path        string                 `json:"path"` // Raw request path.
+	URL         string                 `json:"url"`  // Request URI including the query string.
+	Host        string                 `json:"host"`
+	RemoteAddr  string                 `json:"remoteAddr"`
+	Route       string                 `json:"route"` // Matched route, e.g. /users/:user_id.
+	Params      MockRequestParams      `json:"params"`
+	Query       MockRequestQuery       `json:"query"`       // First value of every query parameter.
+	QueryValues MockRequestQueryValues `json:"queryValues"` // All the values of every query parameter.
+	Headers     MockRequestHeaders     `json:"headers"`
+	ContentType string                 `json:"contentType"` // Media type without parameters.
+	Body        MockRequestBody        `json:"body"`
+	Files       []MockRequestFile      `json:"files"`      // Uploaded files metadata for multipart requests.
+	RawBody     string                 `json:"rawBody"`    // Base64 encoded raw body.
+	ClientCert  *xtls.ClientCert       `json:"clientCert"` // Verified client certificate if mutual TLS is enabled.
 }
 
 func NewMockRequestFrom(r bunrouter.Request) (MockRequest, error) {
-	var body MockRequestBody
-	if err := render.DecodeJSON(r.Body, &body); err != nil && !errors.Is(err, io.EOF) {
-		return MockRequest{}, fmt.Errorf("decode json body: %w", err)
+	rawBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		return MockRequest{}, fmt.Errorf("read body: %w", err)
+	}
+
+	contentType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	body, files, err := decodeBody(contentType, params, rawBody)
+	if err != nil {
+		return MockRequest{}, fmt.Errorf("decode body: %w", err)
 	}
 
 	headers := make(MockRequestHeaders, len(r.Header))
@@ -34,9 +70,113 @@ func NewMockRequestFrom(r bunrouter.Request) (MockRequest, error) {
 		headers[header] = strings.ToLower(r.Header.Get(header))
 	}
 
+	queryValues := r.URL.Query()
+
 	return MockRequest{
-		Params:  r.Params().Map(),
-		Headers: headers,
-		Body:    body,
+		Method:      r.Method,
+		Path:        r.URL.EscapedPath(),
+		URL:         r.RequestURI,
+		Host:        r.Host,
+		RemoteAddr:  r.RemoteAddr,
+		Route:       r.Route(),
+		Params:      r.Params().Map(),
+		Query:       firstValues(queryValues),
+		QueryValues: MockRequestQueryValues(queryValues),
+		Headers:     headers,
+		ContentType: contentType,
+		Body:        body,
+		Files:       files,
+		RawBody:     base64.StdEncoding.EncodeToString(rawBody),
+		ClientCert:  xtls.NewClientCert(r.TLS),
 	}, nil
 }
+
+// RawBytes decodes the raw body as an ArrayBuffer for the scripts, e.g. to respond it back via request.rawBytes().
+func (r MockRequest) RawBytes(_ goja.FunctionCall, vm *goja.Runtime) goja.Value {
+	data, err := base64.StdEncoding.DecodeString(r.RawBody)
+	if err != nil {
+		panic(vm.NewGoError(fmt.Errorf("decode raw body: %w", err)))
+	}
+
+	return vm.ToValue(vm.NewArrayBuffer(data))
+}
+
+// ----------------------------------------------------------------------------
+
+// decodeBody decodes the body according to the content type, unknown types are left for the raw body only.
+func decodeBody(contentType string, params map[string]string, data []byte) (MockRequestBody, []MockRequestFile, error) {
+	switch {
+	case contentType == "", contentType == "application/json", strings.HasSuffix(contentType, "+json"):
+		var body MockRequestBody
+		if err := render.DecodeJSON(bytes.NewReader(data), &body); err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("decode json body: %w", err)
+		}
+
+		return body, nil, nil
+	case contentType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(xstrings.ByteSliceToString(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("decode form body: %w", err)
+		}
+
+		return firstValues(values), nil, nil
+	case contentType == "multipart/form-data":
+		return decodeMultipart(params["boundary"], data)
+	case strings.HasPrefix(contentType, "text/"):
+		return string(data), nil, nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+// decodeMultipart returns the form fields as a body and the metadata of the uploaded files.
+func decodeMultipart(boundary string, data []byte) (MockRequestBody, []MockRequestFile, error) {
+	var (
+		fields = make(map[string][]string)
+		files  []MockRequestFile
+	)
+
+	reader := multipart.NewReader(bytes.NewReader(data), boundary)
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("read multipart part: %w", err)
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read multipart part content: %w", err)
+		}
+
+		if part.FileName() == "" {
+			fields[part.FormName()] = append(fields[part.FormName()], string(content))
+
+			continue
+		}
+
+		files = append(files, MockRequestFile{
+			Field:       part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        int64(len(content)),
+		})
+	}
+
+	return firstValues(fields), files, nil
+}
+
+func firstValues(values map[string][]string) map[string]string {
+	first := make(map[string]string, len(values))
+	for key, vals := range values {
+		if len(vals) > 0 {
+			first[key] = vals[0]
+		}
+	}
+
+	return first
+}
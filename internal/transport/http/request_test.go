@@ -0,0 +1,192 @@
+package http
+
+import (
+	"bytes"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/sknv/protomock/pkg/http/render"
+)
+
+func TestNewMockRequestFrom(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter(t, map[string]string{
+		"users/:user_id/GET.js":  `({ body: request })`,
+		"users/:user_id/POST.js": `({ body: request })`,
+	}, nil, 0)
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		wantPath        string
+		wantParams      MockRequestParams
+		wantQuery       MockRequestQuery
+		wantQueryValues MockRequestQueryValues
+	}{
+		{
+			name:            "no query",
+			method:          http.MethodGet,
+			target:          "/users/1",
+			wantPath:        "/users/1",
+			wantParams:      MockRequestParams{"user_id": "1"},
+			wantQuery:       MockRequestQuery{},
+			wantQueryValues: MockRequestQueryValues{},
+		},
+		{
+			name:            "repeated query",
+			method:          http.MethodGet,
+			target:          "/users/1?tag=a&tag=b&page=2",
+			wantPath:        "/users/1",
+			wantParams:      MockRequestParams{"user_id": "1"},
+			wantQuery:       MockRequestQuery{"tag": "a", "page": "2"},
+			wantQueryValues: MockRequestQueryValues{"tag": {"a", "b"}, "page": {"2"}},
+		},
+		{
+			name:            "escaped path",
+			method:          http.MethodPost,
+			target:          "/users/John%20Doe?q=a%26b",
+			wantPath:        "/users/John%20Doe",
+			wantParams:      MockRequestParams{"user_id": "John Doe"},
+			wantQuery:       MockRequestQuery{"q": "a&b"},
+			wantQueryValues: MockRequestQueryValues{"q": {"a&b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+
+			var got MockRequest
+			if err := render.DecodeJSON(recorder.Body, &got); err != nil {
+				t.Fatalf("decode request error = %v, body = %q", err, recorder.Body)
+			}
+
+			if got.Method != tt.method || got.Path != tt.wantPath || got.URL != tt.target {
+				t.Errorf("request = %s %s (%s), want %s %s (%s)",
+					got.Method, got.Path, got.URL, tt.method, tt.wantPath, tt.target)
+			}
+
+			if got.Route != "/users/:user_id" {
+				t.Errorf("request route = %q, want %q", got.Route, "/users/:user_id")
+			}
+
+			if !maps.Equal(got.Params, tt.wantParams) {
+				t.Errorf("request params = %v, want %v", got.Params, tt.wantParams)
+			}
+
+			if !maps.Equal(got.Query, tt.wantQuery) {
+				t.Errorf("request query = %v, want %v", got.Query, tt.wantQuery)
+			}
+
+			if !maps.EqualFunc(got.QueryValues, tt.wantQueryValues, slices.Equal) {
+				t.Errorf("request query values = %v, want %v", got.QueryValues, tt.wantQueryValues)
+			}
+		})
+	}
+}
+
+func TestMockRequestRawBytes(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter(t, map[string]string{
+		"buffer/POST.js": `({ body: request.rawBytes() })`,
+		"typed/POST.js":  `({ body: new Uint8Array(request.rawBytes()) })`,
+	}, nil, 0)
+
+	body := []byte{0x00, 0x01, 0xfe, 0xff, 'J', 'o', 'h', 'n'}
+
+	for _, target := range []string{"/buffer", "/typed"} {
+		request := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		request.Header.Set("Content-Type", "image/png")
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if got := recorder.Header().Get("Content-Type"); got != "application/octet-stream" {
+			t.Errorf("%s: response content type = %q, want %q", target, got, "application/octet-stream")
+		}
+
+		if got := recorder.Body.Bytes(); !bytes.Equal(got, body) {
+			t.Errorf("%s: response body = %v, want %v", target, got, body)
+		}
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	t.Parallel()
+
+	var multipartBody bytes.Buffer
+
+	writer := multipart.NewWriter(&multipartBody)
+	_ = writer.WriteField("name", "John")
+	file, _ := writer.CreateFormFile("avatar", "avatar.png")
+	_, _ = file.Write([]byte("png"))
+	_ = writer.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		params      map[string]string
+		data        string
+		want        MockRequestBody
+		wantFiles   []MockRequestFile
+		wantErr     bool
+	}{
+		{name: "no content type", contentType: "", params: nil, data: `{"id": 1}`, want: map[string]any{"id": 1.0}, wantFiles: nil, wantErr: false},                     //nolint:lll
+		{name: "json", contentType: "application/json", params: nil, data: `[1, "a"]`, want: []any{1.0, "a"}, wantFiles: nil, wantErr: false},                           //nolint:lll
+		{name: "json suffix", contentType: "application/problem+json", params: nil, data: `{"id": 1}`, want: map[string]any{"id": 1.0}, wantFiles: nil, wantErr: false}, //nolint:lll
+		{name: "empty json", contentType: "application/json", params: nil, data: "", want: nil, wantFiles: nil, wantErr: false},
+		{name: "invalid json", contentType: "application/json", params: nil, data: `{"id":`, want: nil, wantFiles: nil, wantErr: true},
+		{name: "form", contentType: "application/x-www-form-urlencoded", params: nil, data: "name=John&tag=a&tag=b", want: map[string]string{"name": "John", "tag": "a"}, wantFiles: nil, wantErr: false}, //nolint:lll
+		{name: "invalid form", contentType: "application/x-www-form-urlencoded", params: nil, data: "name=%zz", want: nil, wantFiles: nil, wantErr: true},                                                 //nolint:lll
+		{
+			name:        "multipart",
+			contentType: "multipart/form-data",
+			params:      map[string]string{"boundary": writer.Boundary()},
+			data:        multipartBody.String(),
+			want:        map[string]string{"name": "John"},
+			wantFiles:   []MockRequestFile{{Field: "avatar", Filename: "avatar.png", ContentType: "application/octet-stream", Size: 3}},
+			wantErr:     false,
+		},
+		{
+			name:        "truncated multipart",
+			contentType: "multipart/form-data",
+			params:      map[string]string{"boundary": writer.Boundary()},
+			data:        multipartBody.String()[:multipartBody.Len()/2],
+			want:        nil,
+			wantFiles:   nil,
+			wantErr:     true,
+		},
+		{name: "text", contentType: "text/csv", params: nil, data: "id,name\n1,John", want: "id,name\n1,John", wantFiles: nil, wantErr: false}, //nolint:lll
+		{name: "unknown", contentType: "application/octet-stream", params: nil, data: "\x01\x02", want: nil, wantFiles: nil, wantErr: false},   //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, files, err := decodeBody(tt.contentType, tt.params, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeBody() = %#v, want %#v", got, tt.want)
+			}
+
+			if !slices.Equal(files, tt.wantFiles) {
+				t.Errorf("decodeBody() files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
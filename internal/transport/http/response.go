@@ -1,23 +1,158 @@
 package http
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/sknv/protomock/pkg/delay"
+	"github.com/sknv/protomock/pkg/document"
+	"github.com/sknv/protomock/pkg/fault"
 	"github.com/sknv/protomock/pkg/http/render"
+	xstrings "github.com/sknv/protomock/pkg/strings"
 )
 
-type MockResponseBody any
+const (
+	_jsonContentType   = "application/json"
+	_textContentType   = "text/plain; charset=utf-8"
+	_binaryContentType = "application/octet-stream"
+)
+
+type (
+	MockResponseHeaders map[string]any // A value is either a string or a list of strings.
+	MockResponseBody    any
+)
+
+type MockResponseCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path"`
+	Domain   string `json:"domain"`
+	MaxAge   int    `json:"maxAge"` // Seconds, a negative value deletes the cookie.
+	Secure   bool   `json:"secure"`
+	HTTPOnly bool   `json:"httpOnly"`
+	SameSite string `json:"sameSite"` // Either lax, strict or none.
+}
 
 type MockResponse struct {
-	Status int              `json:"status"`
-	Body   MockResponseBody `json:"body"`
+	Status      int                  `json:"status"`
+	Headers     MockResponseHeaders  `json:"headers"`
+	Cookies     []MockResponseCookie `json:"cookies"`
+	ContentType string               `json:"contentType"` // Guessed from the body type if not provided.
+	Body        MockResponseBody     `json:"body"`
+	Delay       any                  `json:"delay"` // Delay before responding, see delay.Parse.
+	Fault       any                  `json:"fault"` // Breaks the response on purpose, see fault.Parse.
+}
+
+// decodeStaticResponse decodes the response of a JSON or YAML file validating its delay and fault right away.
+func decodeStaticResponse(file string, data []byte) (MockResponse, error) {
+	var response MockResponse
+	if err := document.Decode(file, data, &response); err != nil {
+		return MockResponse{}, fmt.Errorf("decode response: %w", err)
+	}
+
+	if _, err := delay.Parse(response.Delay); err != nil {
+		return MockResponse{}, fmt.Errorf("parse delay: %w", err)
+	}
+
+	if _, err := fault.Parse(response.Fault); err != nil {
+		return MockResponse{}, fmt.Errorf("parse fault: %w", err)
+	}
+
+	return response, nil
 }
 
-func (r MockResponse) JSON(w http.ResponseWriter) error {
-	if err := render.JSON(w, r.Status, r.Body); err != nil {
-		return fmt.Errorf("render json: %w", err)
+// Wait pauses the request for the response delay or until the request is canceled.
+func (r MockResponse) Wait(ctx context.Context) error {
+	pause, err := delay.Parse(r.Delay)
+	if err != nil {
+		return fmt.Errorf("parse delay: %w", err)
+	}
+
+	return pause.Sleep(ctx) //nolint:wrapcheck // proxy
+}
+
+// Render writes the response, the body is omitted if it is not provided, e.g. for 204 or redirects.
+// Strings and bytes are written as is, any other body is rendered as JSON.
+func (r MockResponse) Render(w http.ResponseWriter) error {
+	header := w.Header()
+	for key, value := range r.Headers {
+		for _, val := range headerValues(value) {
+			header.Add(key, val)
+		}
+	}
+
+	for _, cookie := range r.Cookies {
+		http.SetCookie(w, cookie.HTTP())
+	}
+
+	status := r.StatusCode()
+
+	switch body := r.Body.(type) {
+	case nil:
+		w.WriteHeader(status)
+	case string:
+		if err := render.Data(w, status, r.contentType(header, _textContentType), xstrings.StringToByteSlice(body)); err != nil {
+			return fmt.Errorf("render text: %w", err)
+		}
+	case []byte:
+		if err := render.Data(w, status, r.contentType(header, _binaryContentType), body); err != nil {
+			return fmt.Errorf("render bytes: %w", err)
+		}
+	default:
+		if err := render.JSONAs(w, status, r.contentType(header, _jsonContentType), body); err != nil {
+			return fmt.Errorf("render json: %w", err)
+		}
 	}
 
 	return nil
 }
+
+// StatusCode returns the response status, 200 by default.
+func (r MockResponse) StatusCode() int {
+	return cmp.Or(r.Status, http.StatusOK)
+}
+
+// contentType chooses the explicitly provided content type, then the one from the headers, then the default one.
+func (r MockResponse) contentType(header http.Header, defaultType string) string {
+	return cmp.Or(r.ContentType, header.Get("Content-Type"), defaultType)
+}
+
+func (c MockResponseCookie) HTTP() *http.Cookie {
+	return &http.Cookie{ //nolint:exhaustruct // only script defined fields
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+		SameSite: sameSiteModes[strings.ToLower(c.SameSite)],
+	}
+}
+
+//nolint:gochecknoglobals // constants
+var sameSiteModes = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// headerValues normalizes a header value provided by a script.
+func headerValues(value any) []string {
+	switch val := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		values := make([]string, 0, len(val))
+		for _, v := range val {
+			values = append(values, fmt.Sprint(v))
+		}
+
+		return values
+	default:
+		return []string{fmt.Sprint(val)}
+	}
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestMockResponseRenderHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		response    MockResponse
+		wantStatus  int
+		wantHeaders http.Header
+		wantCookies []string
+		wantBody    string
+	}{
+		{
+			name:        "status only",
+			response:    MockResponse{Status: http.StatusNoContent}, //nolint:exhaustruct
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: http.Header{"Content-Type": nil},
+			wantCookies: nil,
+			wantBody:    "",
+		},
+		{
+			name: "redirect",
+			response: MockResponse{ //nolint:exhaustruct
+				Status:  http.StatusFound,
+				Headers: MockResponseHeaders{"Location": "/login"},
+			},
+			wantStatus:  http.StatusFound,
+			wantHeaders: http.Header{"Location": {"/login"}, "Content-Type": nil},
+			wantCookies: nil,
+			wantBody:    "",
+		},
+		{
+			name: "multi-value headers",
+			response: MockResponse{ //nolint:exhaustruct
+				Headers: MockResponseHeaders{"X-Tag": []any{"a", "b"}, "X-Count": 2, "X-Empty": nil},
+				Body:    "ok",
+			},
+			wantStatus:  http.StatusOK,
+			wantHeaders: http.Header{"X-Tag": {"a", "b"}, "X-Count": {"2"}, "X-Empty": nil},
+			wantCookies: nil,
+			wantBody:    "ok",
+		},
+		{
+			name: "cookies",
+			response: MockResponse{ //nolint:exhaustruct
+				Cookies: []MockResponseCookie{
+					{Name: "session", Value: "abc", Path: "/", HTTPOnly: true, Secure: true, SameSite: "Strict"}, //nolint:exhaustruct
+					{Name: "theme", Value: "", MaxAge: -1},                                                       //nolint:exhaustruct
+				},
+			},
+			wantStatus:  http.StatusOK,
+			wantHeaders: nil,
+			wantCookies: []string{
+				"session=abc; Path=/; HttpOnly; Secure; SameSite=Strict",
+				"theme=; Max-Age=0",
+			},
+			wantBody: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			if err := tt.response.Render(recorder); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("Render() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			for key, want := range tt.wantHeaders {
+				if got := recorder.Header().Values(key); !slices.Equal(got, want) {
+					t.Errorf("Render() header %s = %q, want %q", key, got, want)
+				}
+			}
+
+			if got := recorder.Header().Values("Set-Cookie"); !slices.Equal(got, tt.wantCookies) {
+				t.Errorf("Render() cookies = %q, want %q", got, tt.wantCookies)
+			}
+
+			if got := recorder.Body.String(); got != tt.wantBody {
+				t.Errorf("Render() body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMockResponseRenderBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		response        MockResponse
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "nil", response: MockResponse{Body: nil}, wantContentType: "", wantBody: ""},                                                                                                                                                      //nolint:exhaustruct,lll
+		{name: "string", response: MockResponse{Body: "Hello"}, wantContentType: "text/plain; charset=utf-8", wantBody: "Hello"},                                                                                                                 //nolint:exhaustruct,lll
+		{name: "bytes", response: MockResponse{Body: []byte{0x01, 0x02}}, wantContentType: "application/octet-stream", wantBody: "\x01\x02"},                                                                                                     //nolint:exhaustruct,lll
+		{name: "object", response: MockResponse{Body: map[string]any{"id": 1}}, wantContentType: "application/json", wantBody: `{"id":1}` + "\n"},                                                                                                //nolint:exhaustruct,lll
+		{name: "explicit content type", response: MockResponse{ContentType: "text/csv", Body: "id\n1"}, wantContentType: "text/csv", wantBody: "id\n1"},                                                                                          //nolint:exhaustruct,lll
+		{name: "header content type", response: MockResponse{Headers: MockResponseHeaders{"Content-Type": "application/problem+json"}, Body: map[string]any{"id": 1}}, wantContentType: "application/problem+json", wantBody: `{"id":1}` + "\n"}, //nolint:exhaustruct,lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			if err := tt.response.Render(recorder); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if got := recorder.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Render() content type = %q, want %q", got, tt.wantContentType)
+			}
+
+			if got := recorder.Body.String(); got != tt.wantBody {
+				t.Errorf("Render() body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+//nolint:gochecknoglobals // constants
+var _hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Upstream forwards requests to a real service.
+type Upstream struct {
+	url    *url.URL
+	client *http.Client
+	proxy  *httputil.ReverseProxy
+}
+
+func NewUpstream(rawURL string) (*Upstream, error) {
+	upstreamURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse upstream url: %w", err)
+	}
+
+	if upstreamURL.Scheme == "" || upstreamURL.Host == "" {
+		return nil, fmt.Errorf("upstream url %q must be absolute", rawURL) //nolint:err113 // dynamic error
+	}
+
+	return &Upstream{
+		url: upstreamURL,
+		client: &http.Client{ //nolint:exhaustruct // defaults
+			// Keep the redirects as is to pass them to the client.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		proxy: &httputil.ReverseProxy{ //nolint:exhaustruct // defaults
+			Rewrite: func(req *httputil.ProxyRequest) {
+				req.SetURL(upstreamURL)
+				req.SetXForwarded()
+			},
+			ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+			},
+		},
+	}, nil
+}
+
+// Proxy passes the request to the upstream streaming its response back, the response status is returned.
+func (u *Upstream) Proxy(w http.ResponseWriter, r *http.Request) int {
+	writer := &statusWriter{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+	}
+
+	u.proxy.ServeHTTP(writer, r)
+
+	return writer.status
+}
+
+// UpstreamResponse is a fully read response of the upstream.
+type UpstreamResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Forward sends the request to the upstream keeping its path, query, headers and body.
+func (u *Upstream) Forward(r *http.Request) (UpstreamResponse, error) {
+	target := u.url.JoinPath(r.URL.Path)
+	target.RawQuery = r.URL.RawQuery
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), r.Body)
+	if err != nil {
+		return UpstreamResponse{}, fmt.Errorf("create upstream request: %w", err)
+	}
+
+	req.ContentLength = r.ContentLength
+	req.Header = r.Header.Clone()
+	removeHopHeaders(req.Header)
+	req.Header.Del("Accept-Encoding") // Let the transport negotiate and decode the compression.
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return UpstreamResponse{}, fmt.Errorf("send upstream request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return UpstreamResponse{}, fmt.Errorf("read upstream response: %w", err)
+	}
+
+	header := resp.Header.Clone()
+	removeHopHeaders(header)
+
+	if resp.Uncompressed {
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+	}
+
+	return UpstreamResponse{
+		Status: resp.StatusCode,
+		Header: header,
+		Body:   body,
+	}, nil
+}
+
+// Render writes the upstream response as is.
+func (r UpstreamResponse) Render(w http.ResponseWriter) error {
+	header := w.Header()
+	for key, values := range r.Header {
+		header[key] = values
+	}
+
+	w.WriteHeader(r.Status)
+
+	if _, err := w.Write(r.Body); err != nil && !errors.Is(err, http.ErrBodyNotAllowed) {
+		return fmt.Errorf("write upstream body: %w", err)
+	}
+
+	return nil
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, key := range _hopHeaders {
+		header.Del(key)
+	}
+}
+
+// statusWriter keeps the response status.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap allows to flush the streamed responses.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/uptrace/bunrouter"
+
+	"github.com/sknv/protomock/internal/journal"
+	"github.com/sknv/protomock/pkg/http/middleware"
+	"github.com/sknv/protomock/pkg/js"
+	"github.com/sknv/protomock/pkg/option"
+)
+
+func TestHandleUnmatchedUpstream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		record       bool
+		upstreamDown bool
+		wantStatus   int
+		wantBody     string
+		wantUpstream bool   // The upstream is called.
+		wantFile     string // Recorded mock relative to the mocks dir if any.
+	}{
+		{name: "mocked route", method: http.MethodGet, target: "/hello", record: false, upstreamDown: false, wantStatus: http.StatusOK, wantBody: "Hello", wantUpstream: false, wantFile: ""},                                           //nolint:lll
+		{name: "unmatched route", method: http.MethodGet, target: "/users/42?tag=a", record: false, upstreamDown: false, wantStatus: http.StatusCreated, wantBody: "GET /users/42?tag=a", wantUpstream: true, wantFile: ""},             //nolint:lll
+		{name: "unmatched method", method: http.MethodPost, target: "/hello", record: false, upstreamDown: false, wantStatus: http.StatusCreated, wantBody: "POST /hello", wantUpstream: true, wantFile: ""},                            //nolint:lll
+		{name: "record", method: http.MethodGet, target: "/users/42?tag=a", record: true, upstreamDown: false, wantStatus: http.StatusCreated, wantBody: "GET /users/42?tag=a", wantUpstream: true, wantFile: "users/__user_id/GET.js"}, //nolint:lll
+		{name: "upstream down", method: http.MethodGet, target: "/users/42", record: false, upstreamDown: true, wantStatus: http.StatusBadGateway, wantBody: "", wantUpstream: false, wantFile: ""},                                     //nolint:lll
+		{name: "record upstream down", method: http.MethodGet, target: "/users/42", record: true, upstreamDown: true, wantStatus: http.StatusBadGateway, wantBody: "", wantUpstream: false, wantFile: ""},                               //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("X-Upstream", "1")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(r.Method + " " + r.URL.RequestURI()))
+			}))
+			if tt.upstreamDown {
+				upstreamServer.Close()
+			} else {
+				t.Cleanup(upstreamServer.Close)
+			}
+
+			upstream, err := NewUpstream(upstreamServer.URL)
+			if err != nil {
+				t.Fatalf("NewUpstream() error = %v", err)
+			}
+
+			mocksDir := t.TempDir()
+			writeFile(t, filepath.Join(mocksDir, "hello", "GET.js"), `({ body: "Hello" })`)
+
+			recorder := option.None[*Recorder]()
+			if tt.record {
+				recorder = option.Some(NewRecorder(mocksDir))
+			}
+
+			router := newUpstreamRouter(t, mocksDir, upstream, recorder)
+
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("%s %s status = %d, want %d", tt.method, tt.target, response.Code, tt.wantStatus)
+			}
+
+			if tt.wantBody != "" && response.Body.String() != tt.wantBody {
+				t.Errorf("%s %s body = %q, want %q", tt.method, tt.target, response.Body, tt.wantBody)
+			}
+
+			if (calls.Load() > 0) != tt.wantUpstream {
+				t.Errorf("upstream calls = %d, want called %v", calls.Load(), tt.wantUpstream)
+			}
+
+			if tt.wantUpstream && response.Header().Get("X-Upstream") != "1" {
+				t.Errorf("upstream header = %q, want %q", response.Header().Get("X-Upstream"), "1")
+			}
+
+			if tt.wantFile != "" {
+				if _, err = os.Stat(filepath.Join(mocksDir, filepath.FromSlash(tt.wantFile))); err != nil {
+					t.Errorf("recorded mock error = %v", err)
+				}
+			} else if _, err = os.Stat(filepath.Join(mocksDir, "users")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("mocks dir has a recorded mock, error = %v", err)
+			}
+		})
+	}
+}
+
+func newUpstreamRouter(
+	t *testing.T, mocksDir string, upstream *Upstream, recorder option.Option[*Recorder],
+) *bunrouter.Router {
+	t.Helper()
+
+	mocks, err := BuildMocks(mocksDir, "")
+	if err != nil {
+		t.Fatalf("BuildMocks() error = %v", err)
+	}
+
+	handlers, err := NewHandlers(
+		mocks, option.Some(journal.New(100)), js.NewPool(1, 0, nil), option.Some(upstream), recorder,
+		nil, js.Timeouts{Default: 0, ByKey: nil},
+	)
+	if err != nil {
+		t.Fatalf("NewHandlers() error = %v", err)
+	}
+
+	router := bunrouter.New(bunrouter.Use(middleware.HandleError))
+	handlers.Route(router)
+
+	return router
+}
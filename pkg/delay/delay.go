@@ -0,0 +1,127 @@
+package delay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	DistributionUniform   = "uniform"
+	DistributionNormal    = "normal"
+	DistributionLognormal = "lognormal"
+)
+
+var errInvalidDelay = errors.New("invalid delay")
+
+// Delay is either a fixed delay or a random one following a distribution, all the values are in milliseconds.
+type Delay struct {
+	Fixed        float64 `json:"-"`
+	Distribution string  `json:"distribution"`
+	Min          float64 `json:"min"`    // Lower bound of uniform distribution.
+	Max          float64 `json:"max"`    // Upper bound of uniform distribution, an optional cap for the others.
+	Mean         float64 `json:"mean"`   // Mean of normal distribution.
+	StdDev       float64 `json:"stddev"` // Standard deviation of normal distribution.
+	Median       float64 `json:"median"` // Median of lognormal distribution.
+	Sigma        float64 `json:"sigma"`  // Standard deviation of the logarithm of lognormal distribution.
+}
+
+// Parse builds a delay from a script value, which is either a number of milliseconds or a distribution object,
+// e.g. { distribution: "uniform", min: 100, max: 200 }. A missing value means no delay.
+func Parse(value any) (Delay, error) {
+	switch val := value.(type) {
+	case nil:
+		return Delay{}, nil
+	case int:
+		return Delay{Fixed: float64(val)}, nil //nolint:exhaustruct // fixed delay
+	case int64:
+		return Delay{Fixed: float64(val)}, nil //nolint:exhaustruct // fixed delay
+	case float64:
+		return Delay{Fixed: val}, nil //nolint:exhaustruct // fixed delay
+	case map[string]any:
+		return parseDistribution(val)
+	default:
+		return Delay{}, fmt.Errorf("%w: unsupported value %v", errInvalidDelay, value)
+	}
+}
+
+// Duration returns the fixed delay or a random one following the distribution.
+func (d Delay) Duration() time.Duration {
+	var millis float64
+
+	//nolint:gosec // no need for a secure random
+	switch d.Distribution {
+	case DistributionUniform:
+		millis = d.Min + rand.Float64()*(d.Max-d.Min)
+	case DistributionNormal:
+		millis = d.capped(d.Mean + rand.NormFloat64()*d.StdDev)
+	case DistributionLognormal:
+		millis = d.capped(d.Median * math.Exp(rand.NormFloat64()*d.Sigma))
+	default:
+		millis = d.Fixed
+	}
+
+	return time.Duration(max(millis, 0) * float64(time.Millisecond))
+}
+
+// Sleep pauses the current goroutine for the delay or until the context is done.
+func (d Delay) Sleep(ctx context.Context) error {
+	return Sleep(ctx, d.Duration())
+}
+
+// capped limits the value by the max if it is set.
+func (d Delay) capped(millis float64) float64 {
+	if d.Max > 0 {
+		return min(millis, d.Max)
+	}
+
+	return millis
+}
+
+// Sleep pauses the current goroutine for the duration or until the context is done.
+func Sleep(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // proxy
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+func parseDistribution(value map[string]any) (Delay, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Delay{}, fmt.Errorf("encode delay: %w", err)
+	}
+
+	var delay Delay
+	if err = json.Unmarshal(data, &delay); err != nil {
+		return Delay{}, fmt.Errorf("%w: %w", errInvalidDelay, err)
+	}
+
+	switch delay.Distribution {
+	case DistributionUniform:
+		if delay.Max < delay.Min {
+			return Delay{}, fmt.Errorf("%w: max is less than min", errInvalidDelay)
+		}
+	case DistributionNormal, DistributionLognormal:
+	default:
+		return Delay{}, fmt.Errorf("%w: unknown distribution %q", errInvalidDelay, delay.Distribution)
+	}
+
+	return delay, nil
+}
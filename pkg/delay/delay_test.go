@@ -0,0 +1,125 @@
+package delay
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   any
+		want    Delay
+		wantErr error
+	}{
+		{name: "missing", value: nil, want: Delay{}, wantErr: nil},
+		{name: "int", value: 100, want: Delay{Fixed: 100}, wantErr: nil},          //nolint:exhaustruct
+		{name: "int64", value: int64(100), want: Delay{Fixed: 100}, wantErr: nil}, //nolint:exhaustruct
+		{name: "float64", value: 100.5, want: Delay{Fixed: 100.5}, wantErr: nil},  //nolint:exhaustruct
+		{name: "string", value: "100", want: Delay{}, wantErr: errInvalidDelay},
+		{
+			name:    "uniform",
+			value:   map[string]any{"distribution": DistributionUniform, "min": 100, "max": 200},
+			want:    Delay{Distribution: DistributionUniform, Min: 100, Max: 200}, //nolint:exhaustruct
+			wantErr: nil,
+		},
+		{
+			name:    "uniform with max less than min",
+			value:   map[string]any{"distribution": DistributionUniform, "min": 200, "max": 100},
+			want:    Delay{},
+			wantErr: errInvalidDelay,
+		},
+		{
+			name:    "normal",
+			value:   map[string]any{"distribution": DistributionNormal, "mean": 100, "stddev": 10, "max": 150},
+			want:    Delay{Distribution: DistributionNormal, Mean: 100, StdDev: 10, Max: 150}, //nolint:exhaustruct
+			wantErr: nil,
+		},
+		{
+			name:    "lognormal",
+			value:   map[string]any{"distribution": DistributionLognormal, "median": 100, "sigma": 0.5},
+			want:    Delay{Distribution: DistributionLognormal, Median: 100, Sigma: 0.5}, //nolint:exhaustruct
+			wantErr: nil,
+		},
+		{
+			name:    "unknown distribution",
+			value:   map[string]any{"distribution": "poisson"},
+			want:    Delay{},
+			wantErr: errInvalidDelay,
+		},
+		{
+			name:    "missing distribution",
+			value:   map[string]any{"min": 100},
+			want:    Delay{},
+			wantErr: errInvalidDelay,
+		},
+		{
+			name:    "invalid field type",
+			value:   map[string]any{"distribution": DistributionUniform, "min": "100"},
+			want:    Delay{},
+			wantErr: errInvalidDelay,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelayDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		delay    Delay
+		min, max time.Duration
+	}{
+		{name: "none", delay: Delay{}, min: 0, max: 0},
+		{name: "fixed", delay: Delay{Fixed: 100}, min: 100 * time.Millisecond, max: 100 * time.Millisecond}, //nolint:exhaustruct
+		{name: "negative fixed", delay: Delay{Fixed: -100}, min: 0, max: 0},                                 //nolint:exhaustruct
+		{
+			name:  "uniform",
+			delay: Delay{Distribution: DistributionUniform, Min: 100, Max: 200}, //nolint:exhaustruct
+			min:   100 * time.Millisecond,
+			max:   200 * time.Millisecond,
+		},
+		{
+			name:  "capped normal",
+			delay: Delay{Distribution: DistributionNormal, Mean: 1000, StdDev: 1000, Max: 150}, //nolint:exhaustruct
+			min:   0,
+			max:   150 * time.Millisecond,
+		},
+		{
+			name:  "capped lognormal",
+			delay: Delay{Distribution: DistributionLognormal, Median: 100, Sigma: 2, Max: 150}, //nolint:exhaustruct
+			min:   0,
+			max:   150 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for range 100 {
+				if got := tt.delay.Duration(); got < tt.min || got > tt.max {
+					t.Fatalf("Duration() = %s, want within [%s, %s]", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
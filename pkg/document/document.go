@@ -0,0 +1,85 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+// IsFile reports whether the file is a JSON or YAML document judging by its extension.
+func IsFile(path string) bool {
+	return IsJSON(path) || IsYAML(path)
+}
+
+// IsJSON reports whether the file is a JSON document.
+func IsJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// IsYAML reports whether the file is a YAML document.
+func IsYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// ToJSON returns the document of the file as JSON, a YAML document is converted.
+func ToJSON(path string, data []byte) ([]byte, error) {
+	if !IsYAML(path) {
+		return data, nil
+	}
+
+	var value any
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("decode yaml: %w", err)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode json: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// Decode decodes the document of the file into the target by its json tags for both JSON and YAML,
+// unknown fields are rejected to catch typos.
+func Decode(path string, data []byte, target any) error {
+	data, err := ToJSON(path, data)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(target); err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+
+	return nil
+}
+
+// Normalize converts a value to plain maps, slices and scalars using its JSON representation,
+// so that it can be matched and copied without knowing its original type.
+func Normalize(value any) (any, error) {
+	if value == nil {
+		return nil, nil //nolint:nilnil // nil is a valid value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode value: %w", err)
+	}
+
+	var result any
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("decode value: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	TypeConnectionReset = "connection_reset" // Resets the connection before responding.
+	TypeEmptyReply      = "empty_reply"      // Closes the connection without responding.
+	TypeStreamReset     = "stream_reset"     // Resets the HTTP/2 stream of the request, the connection is kept.
+	TypeTruncatedBody   = "truncated_body"   // Sends a half of the body only.
+	TypeMalformedBody   = "malformed_body"   // Sends a body which can't be decoded.
+	TypeSlowDrip        = "slow_drip"        // Sends the body in small chunks with pauses in between.
+)
+
+const (
+	_defaultChunkSize = 1
+	_defaultInterval  = 100
+)
+
+var errInvalidFault = errors.New("invalid fault")
+
+// Fault describes how to break a response instead of sending it normally.
+type Fault struct {
+	Type      string  `json:"type"`
+	ChunkSize int     `json:"chunkSize"` // Bytes per chunk for slow drip, 1 by default.
+	Interval  float64 `json:"interval"`  // Pause between chunks for slow drip in milliseconds, 100 by default.
+}
+
+// Parse builds a fault from a script or config value, which is either a fault type or a fault object,
+// e.g. { type: "slow_drip", chunkSize: 16, interval: 50 }. A missing value means no fault.
+func Parse(value any) (Fault, error) {
+	var fault Fault
+
+	switch val := value.(type) {
+	case nil:
+		return Fault{}, nil
+	case string:
+		fault.Type = val
+	case map[string]any:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return Fault{}, fmt.Errorf("encode fault: %w", err)
+		}
+
+		if err = json.Unmarshal(data, &fault); err != nil {
+			return Fault{}, fmt.Errorf("%w: %w", errInvalidFault, err)
+		}
+	default:
+		return Fault{}, fmt.Errorf("%w: unsupported value %v", errInvalidFault, value)
+	}
+
+	switch fault.Type {
+	case TypeConnectionReset, TypeEmptyReply, TypeStreamReset, TypeTruncatedBody, TypeMalformedBody:
+	case TypeSlowDrip:
+		if fault.ChunkSize <= 0 {
+			fault.ChunkSize = _defaultChunkSize
+		}
+
+		if fault.Interval <= 0 {
+			fault.Interval = _defaultInterval
+		}
+	default:
+		return Fault{}, fmt.Errorf("%w: unknown type %q", errInvalidFault, fault.Type)
+	}
+
+	return fault, nil
+}
+
+// ParseAll parses the faults by their keys, e.g. routes or methods.
+func ParseAll(values map[string]any) (map[string]Fault, error) {
+	faults := make(map[string]Fault, len(values))
+
+	for key, value := range values {
+		fault, err := Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse fault for %s: %w", key, err)
+		}
+
+		faults[key] = fault
+	}
+
+	return faults, nil
+}
+
+// IsNone reports whether there is no fault.
+func (f Fault) IsNone() bool {
+	return f.Type == ""
+}
+
+// IsConnection reports whether the fault breaks the connection, or the stream of an HTTP/2 one, rather than the body.
+func (f Fault) IsConnection() bool {
+	return f.Type == TypeConnectionReset || f.Type == TypeEmptyReply
+}
+
+// PauseDuration returns the pause between the slow drip chunks.
+func (f Fault) PauseDuration() time.Duration {
+	return time.Duration(f.Interval * float64(time.Millisecond))
+}
+
+// Chunks splits the data into the slow drip chunks.
+func (f Fault) Chunks(data []byte) [][]byte {
+	size := max(f.ChunkSize, _defaultChunkSize)
+	chunks := make([][]byte, 0, len(data)/size+1)
+
+	for len(data) > size {
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+
+	return append(chunks, data)
+}
+
+// Corrupt returns the data broken according to the body fault.
+func (f Fault) Corrupt(data []byte) []byte {
+	switch f.Type {
+	case TypeTruncatedBody:
+		return data[:len(data)/2]
+	case TypeMalformedBody:
+		// Keep the beginning of the body to look real and append bytes which are neither valid UTF-8 nor protobuf.
+		return append(data[:len(data)/2:len(data)/2], 0xff, 0xff, 0xff, 0xff)
+	default:
+		return data
+	}
+}
+
+// Break breaks the connection according to the connection fault.
+func (f Fault) Break(conn net.Conn) error {
+	if f.Type == TypeConnectionReset {
+		return Reset(conn)
+	}
+
+	return conn.Close() //nolint:wrapcheck // proxy
+}
+
+// Reset closes the connection with a TCP reset instead of a graceful shutdown if possible,
+// TLS connections are reset without the close notification.
+func Reset(conn net.Conn) error {
+	raw := conn
+	if tlsConn, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		raw = tlsConn.NetConn()
+	}
+
+	if tcpConn, ok := raw.(*net.TCPConn); ok {
+		if err := tcpConn.SetLinger(0); err != nil {
+			return fmt.Errorf("disable linger: %w", err)
+		}
+	}
+
+	return raw.Close() //nolint:wrapcheck // proxy
+}
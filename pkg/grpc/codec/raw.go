@@ -0,0 +1,47 @@
+package codec
+
+import (
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/mem"
+)
+
+// Frame is a message passed through as raw bytes without decoding.
+type Frame struct {
+	Data []byte
+}
+
+// RawCodec passes frames as is and delegates any other messages to the proto codec.
+// It allows to proxy messages of unknown types along with the regular proto messages.
+type RawCodec struct {
+	proto encoding.CodecV2
+}
+
+func NewRawCodec() RawCodec {
+	return RawCodec{
+		proto: encoding.GetCodecV2(proto.Name),
+	}
+}
+
+func (c RawCodec) Marshal(v any) (mem.BufferSlice, error) {
+	if frame, ok := v.(*Frame); ok {
+		return mem.BufferSlice{mem.SliceBuffer(frame.Data)}, nil
+	}
+
+	return c.proto.Marshal(v) //nolint:wrapcheck // proxy
+}
+
+func (c RawCodec) Unmarshal(data mem.BufferSlice, v any) error {
+	if frame, ok := v.(*Frame); ok {
+		frame.Data = data.Materialize()
+
+		return nil
+	}
+
+	return c.proto.Unmarshal(data, v) //nolint:wrapcheck // proxy
+}
+
+// Name returns the proto codec name to be used for the regular gRPC calls.
+func (c RawCodec) Name() string {
+	return proto.Name
+}
@@ -0,0 +1,37 @@
+package ctxlogger
+
+import (
+	"log/slog"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+
+	"github.com/sknv/protomock/pkg/grpc/middleware/requestid"
+	"github.com/sknv/protomock/pkg/log"
+)
+
+// ProvideStreamContextLogger returns a new stream server interceptor that adds slog.Logger to the context.
+func ProvideStreamContextLogger(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		wrapped := middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = log.ToContext(stream.Context(), logger)
+
+		return handler(srv, wrapped)
+	}
+}
+
+// ProvideStreamLogRequestID returns a new stream server interceptor that injects a request id
+// into the context of each stream.
+func ProvideStreamLogRequestID(
+	srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx := stream.Context()
+	requestID := requestid.GetRequestID(ctx)
+
+	wrapped := middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = log.AppendCtx(ctx, slog.String("request_id", requestID))
+
+	return handler(srv, wrapped)
+}
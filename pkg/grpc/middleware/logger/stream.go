@@ -1,7 +1,6 @@
 package logger
 
 import (
-	"context"
 	"log/slog"
 	"time"
 
@@ -11,15 +10,17 @@ import (
 	"github.com/sknv/protomock/pkg/log"
 )
 
-// LogUnaryRequest logs gRPC requests.
+// LogStreamRequest logs gRPC streams.
 //
 //nolint:nonamedreturns // used in defer
-func LogUnaryRequest(
-	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
-) (_ any, err error) {
+func LogStreamRequest(
+	srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) (err error) {
 	start := time.Now()
 
 	defer func() {
+		ctx := stream.Context()
+
 		fields := []any{
 			slog.String("method", info.FullMethod),
 			slog.Uint64("code", uint64(status.Code(err))),
@@ -29,8 +30,8 @@ func LogUnaryRequest(
 			fields = append(fields, slog.Any("error", err))
 		}
 
-		log.FromContext(ctx).InfoContext(ctx, "gRPC request handled", fields...)
+		log.FromContext(ctx).InfoContext(ctx, "gRPC stream handled", fields...)
 	}()
 
-	return handler(ctx, req)
+	return handler(srv, stream)
 }
@@ -0,0 +1,20 @@
+package requestid
+
+import (
+	"context"
+)
+
+const _requestIDHeader = "X-Request-ID"
+
+type ctxKey string
+
+const _requestIDField ctxKey = "request_id"
+
+// GetRequestID returns request id from the context.
+func GetRequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(_requestIDField).(string); ok {
+		return id
+	}
+
+	return ""
+}
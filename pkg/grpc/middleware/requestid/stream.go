@@ -0,0 +1,33 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ProvideStreamRequestID looks for metadata key X-Request-ID and makes it as random id if not found,
+// then populates it to the stream context.
+func ProvideStreamRequestID(
+	srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	var requestID string
+
+	ctx := stream.Context()
+	meta, _ := metadata.FromIncomingContext(ctx)
+
+	headerValues := meta.Get(_requestIDHeader)
+	if len(headerValues) > 0 {
+		requestID = headerValues[0]
+	} else {
+		requestID = rand.Text()
+	}
+
+	wrapped := middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = context.WithValue(ctx, _requestIDField, requestID)
+
+	return handler(srv, wrapped)
+}
@@ -72,3 +72,8 @@ func (c *customResponseWriter) Write(b []byte) (int, error) {
 
 	return size, err //nolint:wrapcheck // proxy
 }
+
+// Unwrap allows to flush or hijack the response via http.ResponseController.
+func (c *customResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
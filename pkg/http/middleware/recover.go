@@ -11,10 +11,15 @@ import (
 )
 
 // Recover is a middleware that recovers from panics, logs the panic and returns a HTTP 500 status if possible.
+// The http.ErrAbortHandler panics are passed through to abort the response.
 func Recover(next bunrouter.HandlerFunc) bunrouter.HandlerFunc {
 	return func(w http.ResponseWriter, r bunrouter.Request) error {
 		defer func() {
 			if rvr := recover(); rvr != nil {
+				if rvr == http.ErrAbortHandler { //nolint:errorlint,err113 // sentinel panic value
+					panic(rvr) // Let the server abort the response.
+				}
+
 				ctx := r.Context()
 				log.FromContext(ctx).ErrorContext(ctx, "Request panic",
 					slog.String("url", r.URL.String()),
@@ -9,8 +9,24 @@ import (
 // JSON renders JSON data response with the provided status,
 // automatically escaping HTML and setting the Content-Type as application/json.
 func JSON(w http.ResponseWriter, status int, data any) error {
-	w.Header().Set("Content-Type", "application/json")
+	return JSONAs(w, status, "application/json", data)
+}
+
+// JSONAs renders JSON data response with the provided status and Content-Type,
+// e.g. application/problem+json.
+func JSONAs(w http.ResponseWriter, status int, contentType string, data any) error {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
 
 	return json.NewEncoder(w).Encode(data) //nolint:wrapcheck
 }
+
+// Data renders raw data response with the provided status and Content-Type.
+func Data(w http.ResponseWriter, status int, contentType string, data []byte) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	_, err := w.Write(data)
+
+	return err //nolint:wrapcheck
+}
@@ -0,0 +1,182 @@
+package js
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/sknv/protomock/pkg/document"
+	xstrings "github.com/sknv/protomock/pkg/strings"
+)
+
+var errNoFixturesDir = errors.New("fixtures dir is not configured")
+
+// Fixtures loads the fixture files of a dir for the scripts. The files can't be loaded from outside the dir,
+// e.g. via ../ or symlinks. The loaded files are cached until they change.
+type Fixtures struct {
+	dir string // Empty if there is no fixtures dir.
+
+	mu    sync.Mutex
+	root  *os.Root            // Opened by the first load, so that a missing dir fails the loads rather than the startup.
+	cache map[string]*fixture // Loaded fixtures by clean paths.
+}
+
+// NewFixtures creates a loader of the fixtures dir, every load fails if the dir is empty.
+func NewFixtures(dir string) *Fixtures {
+	return &Fixtures{
+		dir:   dir,
+		mu:    sync.Mutex{},
+		root:  nil,
+		cache: make(map[string]*fixture),
+	}
+}
+
+// Close closes the fixtures dir if it has been opened.
+func (f *Fixtures) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.root == nil {
+		return nil
+	}
+
+	return f.root.Close() //nolint:wrapcheck // proxy
+}
+
+// Load loads a fixture by the path relative to the fixtures dir, e.g. fixtures.load("users/list.json").
+// JSON and YAML files are parsed, so every call returns a new object to tweak, other files are loaded as text.
+func (f *Fixtures) Load(call goja.FunctionCall, vm *goja.Runtime) goja.Value {
+	path := call.Argument(0).String()
+
+	fxt, err := f.load(path)
+	if err != nil {
+		panic(vm.NewGoError(err))
+	}
+
+	if !document.IsFile(path) {
+		return vm.ToValue(string(fxt.data))
+	}
+
+	data, err := fxt.json(path)
+	if err != nil {
+		panic(vm.NewGoError(fmt.Errorf("parse fixture %s: %w", path, err)))
+	}
+
+	parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+
+	value, err := parse(goja.Undefined(), vm.ToValue(data))
+	if err != nil {
+		panic(err) // Rethrow as is.
+	}
+
+	return value
+}
+
+// LoadText loads a fixture as text whatever its format is.
+func (f *Fixtures) LoadText(call goja.FunctionCall, vm *goja.Runtime) goja.Value {
+	fxt, err := f.load(call.Argument(0).String())
+	if err != nil {
+		panic(vm.NewGoError(err))
+	}
+
+	return vm.ToValue(string(fxt.data))
+}
+
+// LoadBytes loads a fixture as an ArrayBuffer, e.g. to respond an image.
+func (f *Fixtures) LoadBytes(call goja.FunctionCall, vm *goja.Runtime) goja.Value {
+	fxt, err := f.load(call.Argument(0).String())
+	if err != nil {
+		panic(vm.NewGoError(err))
+	}
+
+	return vm.ToValue(vm.NewArrayBuffer(bytes.Clone(fxt.data)))
+}
+
+// load returns the cached fixture reading it again if the file has changed.
+func (f *Fixtures) load(path string) (*fixture, error) {
+	root, err := f.openRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Clean(filepath.FromSlash(path))
+
+	info, err := root.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("load fixture %s: %w", path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if fxt, ok := f.cache[name]; ok && fxt.modTime.Equal(info.ModTime()) && fxt.size == info.Size() {
+		return fxt, nil
+	}
+
+	data, err := root.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("load fixture %s: %w", path, err)
+	}
+
+	fxt := &fixture{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		data:    data,
+		once:    sync.Once{},
+		encoded: "",
+		err:     nil,
+	}
+	f.cache[name] = fxt
+
+	return fxt, nil
+}
+
+// openRoot opens the fixtures dir once it exists, a missing dir is looked up again by the next load.
+func (f *Fixtures) openRoot() (*os.Root, error) {
+	if f.dir == "" {
+		return nil, errNoFixturesDir
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.root == nil {
+		root, err := os.OpenRoot(f.dir)
+		if err != nil {
+			return nil, fmt.Errorf("open fixtures dir: %w", err)
+		}
+
+		f.root = root
+	}
+
+	return f.root, nil
+}
+
+// ----------------------------------------------------------------------------
+
+type fixture struct {
+	modTime time.Time
+	size    int64
+	data    []byte
+
+	// The structured content encoded as JSON once.
+	once    sync.Once
+	encoded string
+	err     error
+}
+
+// json returns the structured content as JSON, YAML files are converted.
+func (f *fixture) json(path string) (string, error) {
+	f.once.Do(func() {
+		data, err := document.ToJSON(path, f.data)
+		f.encoded, f.err = xstrings.ByteSliceToString(data), err
+	})
+
+	return f.encoded, f.err
+}
@@ -0,0 +1,54 @@
+package js
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixturesLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "list.yaml"), []byte("- id: 1\n"), 0o600); err != nil {
+		t.Fatalf("write fixture error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		path    string
+		want    string
+		wantErr error // Any error is expected if the result is empty.
+	}{
+		{name: "existing file", dir: dir, path: "list.yaml", want: "- id: 1\n", wantErr: nil},
+		{name: "missing file", dir: dir, path: "missing.yaml", want: "", wantErr: fs.ErrNotExist},
+		{name: "outside the dir", dir: dir, path: "../list.yaml", want: "", wantErr: nil},
+		{name: "no dir", dir: "", path: "list.yaml", want: "", wantErr: errNoFixturesDir},
+		{name: "missing dir", dir: filepath.Join(dir, "missing"), path: "list.yaml", want: "", wantErr: fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fixtures := NewFixtures(tt.dir)
+			defer fixtures.Close()
+
+			fxt, err := fixtures.load(tt.path)
+			if tt.want == "" && err == nil {
+				t.Fatal("load() error = nil, want an error")
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("load() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.want != "" && (err != nil || string(fxt.data) != tt.want) {
+				t.Errorf("load() = %v, %v, want %q", fxt, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package js
+
+import (
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+// _hardenScript freezes the builtins reachable from the globals, including the hidden prototypes
+// of the iterators and generators, and makes the global bindings of the builtins read-only.
+// The properties which are commonly overridden by the inheriting objects, e.g. Foo.prototype.toString = ...,
+// are turned into accessors defining an own property on assignment, since an assignment to an inherited
+// read-only property fails otherwise.
+const _hardenScript = `(function () {
+  "use strict";
+
+  const { defineProperty, freeze, getOwnPropertyDescriptor, getPrototypeOf } = Object;
+  const ownKeys = Reflect.ownKeys;
+
+  const tame = (object, keys) => {
+    for (const key of keys) {
+      const desc = getOwnPropertyDescriptor(object, key);
+      if (desc === undefined || !("value" in desc)) {
+        continue;
+      }
+
+      const value = desc.value;
+      defineProperty(object, key, {
+        get() {
+          return value;
+        },
+        set(newValue) {
+          if (this === object) {
+            throw new TypeError("Cannot assign to read only property '" + String(key) + "' of a builtin");
+          }
+
+          defineProperty(this, key, { value: newValue, writable: true, enumerable: true, configurable: true });
+        },
+        enumerable: desc.enumerable,
+        configurable: false,
+      });
+    }
+  };
+
+  tame(Object.prototype, ["constructor", "toString", "toLocaleString", "valueOf"]);
+  tame(Function.prototype, ["constructor", "toString"]);
+  tame(Error.prototype, ["constructor", "name", "message", "toString"]);
+
+  const hardened = new WeakSet([globalThis]); // The global object keeps the script globals.
+  const harden = (value) => {
+    if ((typeof value !== "object" && typeof value !== "function") || value === null || hardened.has(value)) {
+      return;
+    }
+
+    hardened.add(value);
+    freeze(value);
+    harden(getPrototypeOf(value));
+
+    for (const key of ownKeys(value)) {
+      const desc = getOwnPropertyDescriptor(value, key);
+      harden(desc.value);
+      harden(desc.get);
+      harden(desc.set);
+    }
+  };
+
+  for (const key of ownKeys(globalThis)) {
+    const desc = getOwnPropertyDescriptor(globalThis, key);
+    if (desc.configurable) {
+      defineProperty(globalThis, key, "value" in desc ? { writable: false, configurable: false } : { configurable: false });
+    }
+
+    harden(desc.value);
+    harden(desc.get);
+    harden(desc.set);
+  }
+
+  harden([][Symbol.iterator]());
+  harden(new Map()[Symbol.iterator]());
+  harden(new Set()[Symbol.iterator]());
+  harden(""[Symbol.iterator]());
+  harden(function* () {});
+  harden(async function () {});
+})()`
+
+//nolint:gochecknoglobals // compiled once
+var _hardenProgram = goja.MustCompile("harden.js", _hardenScript, true)
+
+// harden freezes the builtins of the runtime, so that a script can't change them for the next scripts
+// run by the same runtime, e.g. by adding a property to Object.prototype. It must be called before
+// any globals are set, since the globals defined by then are frozen as well.
+func harden(vm *goja.Runtime) error {
+	if _, err := vm.RunProgram(_hardenProgram); err != nil {
+		return fmt.Errorf("harden builtins: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package js
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+var (
+	// ErrTimeout is returned when a script runs longer than its timeout.
+	ErrTimeout = errors.New("script timeout exceeded")
+	// ErrStackOverflow is returned when a script exceeds the max call stack depth.
+	ErrStackOverflow = errors.New("max call stack depth exceeded")
+)
+
+// Timeouts limit the script run time by keys, e.g. routes or methods, falling back to the default one.
+// A zero timeout means the script is interrupted only once its context is done.
+type Timeouts struct {
+	Default time.Duration
+	ByKey   map[string]time.Duration
+}
+
+// Get returns the timeout for the key.
+func (t Timeouts) Get(key string) time.Duration {
+	if timeout, ok := t.ByKey[key]; ok {
+		return timeout
+	}
+
+	return t.Default
+}
+
+// Run runs the program interrupting it once the context is done or the timeout expires, see Guard.
+func Run(ctx context.Context, vm *goja.Runtime, program *goja.Program, timeout time.Duration) (goja.Value, error) {
+	var result goja.Value
+
+	err := Guard(ctx, vm, timeout, func(context.Context) error {
+		var err error
+		result, err = vm.RunProgram(program)
+
+		return err //nolint:wrapcheck // proxy
+	})
+
+	return result, err
+}
+
+// Guard calls the function running scripts in the runtime interrupting them once the context is done
+// or the timeout expires. The returned error wraps either ErrTimeout or the context error in this case,
+// and ErrStackOverflow if the scripts go deeper than the max call stack depth. The call gets the guarded context,
+// so that the Go functions called back by the scripts, e.g. waiting ones, may stop along with them.
+func Guard(ctx context.Context, vm *goja.Runtime, timeout time.Duration, call func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w after %s", ErrTimeout, timeout))
+		defer cancel()
+	}
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(interrupted)
+
+		vm.Interrupt(context.Cause(ctx))
+	})
+
+	err := call(ctx)
+
+	// The interruption may come right after the call, make sure it does not affect the next one.
+	if !stop() {
+		<-interrupted
+		vm.ClearInterrupt()
+	}
+
+	// The overflow has no message and its stack is as deep as the limit, so point to the innermost frame only.
+	var overflow *goja.StackOverflowError
+	if errors.As(err, &overflow) {
+		if stack := overflow.Stack(); len(stack) > 0 {
+			return fmt.Errorf("%w at %s", ErrStackOverflow, stack[0].Position())
+		}
+
+		return ErrStackOverflow
+	}
+
+	return err
+}
@@ -0,0 +1,60 @@
+package js
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		cancel  bool // Cancel the context shortly after the start.
+		wantErr error
+	}{
+		{name: "completed", script: `1 + 1`, timeout: time.Second, cancel: false, wantErr: nil},
+		{name: "infinite loop", script: `for (;;) {}`, timeout: time.Millisecond * 50, cancel: false, wantErr: ErrTimeout},
+		{name: "infinite loop without timeout", script: `while (true) {}`, timeout: 0, cancel: true, wantErr: context.Canceled},
+		{name: "stack overflow", script: `function f() { return f() }; f()`, timeout: time.Second, cancel: false, wantErr: ErrStackOverflow}, //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			vm := NewRuntime()
+			vm.SetMaxCallStackSize(100)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			if tt.cancel {
+				time.AfterFunc(time.Millisecond*50, cancel)
+			}
+
+			err := Guard(ctx, vm, tt.timeout, func(context.Context) error {
+				_, err := vm.RunString(tt.script)
+
+				return err //nolint:wrapcheck // checked by the test
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Guard() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// The interruption must not affect the next call of the runtime.
+			err = Guard(t.Context(), vm, time.Second, func(context.Context) error {
+				_, err := vm.RunString(`1 + 1`)
+
+				return err //nolint:wrapcheck // checked by the test
+			})
+			if err != nil {
+				t.Errorf("Guard() next call error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package js
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	xstrings "github.com/sknv/protomock/pkg/strings"
+)
+
+const (
+	_defaultLibDir = "lib"
+
+	_moduleFileExtension = ".js"
+	_moduleIndexFile     = "index" + _moduleFileExtension
+)
+
+var errModuleNotFound = errors.New("module not found")
+
+// Modules loads the CommonJS modules required by the scripts. A module is compiled once and cached,
+// while every evaluation gets its own module instances, so that no state leaks between the evaluations.
+// The cache lives as long as the loader, so a new loader is created for every build of the mocks
+// to pick up the changed modules on reload.
+type Modules struct {
+	baseDir string // Relative module paths of the scripts are resolved against it.
+	libDir  string // Bare module names are resolved against it.
+
+	mu       sync.Mutex
+	programs map[string]*goja.Program // Compiled modules by files.
+}
+
+// NewModules creates a loader for the scripts of the base dir, the lib dir is the lib inside the base one by default.
+func NewModules(baseDir, libDir string) *Modules {
+	if libDir == "" {
+		libDir = filepath.Join(baseDir, _defaultLibDir)
+	}
+
+	return &Modules{
+		baseDir:  filepath.Clean(baseDir),
+		libDir:   filepath.Clean(libDir),
+		mu:       sync.Mutex{},
+		programs: make(map[string]*goja.Program),
+	}
+}
+
+// IsLib reports whether the path is inside the lib dir, i.e. it holds modules rather than scripts.
+func (m *Modules) IsLib(path string) bool {
+	rel, err := filepath.Rel(m.libDir, filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// CompileLib compiles all the modules of the lib dir, so that syntax errors are reported
+// on build rather than on the first require. A missing lib dir has no modules.
+func (m *Modules) CompileLib() error {
+	err := filepath.WalkDir(m.libDir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == m.libDir {
+			return filepath.SkipDir
+		}
+
+		if err != nil {
+			return fmt.Errorf("traverse path: %w", err)
+		}
+
+		if entry.IsDir() || filepath.Ext(path) != _moduleFileExtension {
+			return nil
+		}
+
+		_, err = m.compile(path)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("walk lib dir: %w", err)
+	}
+
+	return nil
+}
+
+// Require returns a require function to be set in the runtime for a single evaluation.
+// Relative paths, e.g. ./lib/users, are resolved against the base dir for the scripts
+// and against the module dir for the modules, other ones are resolved against the lib dir.
+func (m *Modules) Require(vm *goja.Runtime) func(goja.FunctionCall) goja.Value {
+	loader := &moduleLoader{
+		modules:   m,
+		vm:        vm,
+		instances: make(map[string]*goja.Object),
+	}
+
+	return loader.require(m.baseDir)
+}
+
+// resolve looks up the module file trying the js extension and the index file like Node.js does.
+func (m *Modules) resolve(dir, id string) (string, error) {
+	base := m.libDir
+	if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") {
+		base = dir
+	}
+
+	path := filepath.Join(base, filepath.FromSlash(id))
+	for _, file := range []string{path, path + _moduleFileExtension, filepath.Join(path, _moduleIndexFile)} {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", errModuleNotFound, id)
+}
+
+// compile returns the cached module program compiling the file on the first call.
+func (m *Modules) compile(file string) (*goja.Program, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if program, ok := m.programs[file]; ok {
+		return program, nil
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read module %s: %w", file, err)
+	}
+
+	// Wrap the module like Node.js does, keeping the line numbers as is.
+	program, err := compile(file, "(function (exports, require, module, __filename, __dirname) {"+
+		xstrings.ByteSliceToString(content)+"\n})")
+	if err != nil {
+		return nil, err
+	}
+
+	m.programs[file] = program
+
+	return program, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// moduleLoader keeps the module instances of a single evaluation.
+type moduleLoader struct {
+	modules   *Modules
+	vm        *goja.Runtime
+	instances map[string]*goja.Object // Module objects by files.
+}
+
+func (l *moduleLoader) require(dir string) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		file, err := l.modules.resolve(dir, call.Argument(0).String())
+		if err != nil {
+			panic(l.vm.NewGoError(err))
+		}
+
+		if module, ok := l.instances[file]; ok {
+			return module.Get("exports")
+		}
+
+		program, err := l.modules.compile(file)
+		if err != nil {
+			panic(l.vm.NewGoError(err))
+		}
+
+		module, err := l.load(file, program)
+		if err != nil {
+			panic(err) // Rethrow the module exceptions and interruptions as is.
+		}
+
+		return module.Get("exports")
+	}
+}
+
+func (l *moduleLoader) load(file string, program *goja.Program) (*goja.Object, error) {
+	wrapper, err := l.vm.RunProgram(program)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // rethrown
+	}
+
+	call, ok := goja.AssertFunction(wrapper)
+	if !ok { // The module source breaks out of the wrapper.
+		panic(l.vm.NewTypeError("module %s is not wrapped properly", file))
+	}
+
+	exports := l.vm.NewObject()
+	module := l.vm.NewObject()
+
+	if err = module.Set("exports", exports); err != nil {
+		panic(l.vm.NewGoError(fmt.Errorf("set module exports: %w", err)))
+	}
+
+	// Keep the instance before running the module, so that circular dependencies get the partial exports.
+	l.instances[file] = module
+
+	if _, err = call(exports, exports, l.vm.ToValue(l.require(filepath.Dir(file))), module,
+		l.vm.ToValue(file), l.vm.ToValue(filepath.Dir(file))); err != nil {
+		delete(l.instances, file)
+
+		return nil, err //nolint:wrapcheck // rethrown
+	}
+
+	return module, nil
+}
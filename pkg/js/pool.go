@@ -0,0 +1,140 @@
+package js
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/dop251/goja"
+)
+
+const _defaultMaxStackDepth = 1000
+
+var errUnknownRuntime = errors.New("runtime is not of the pool")
+
+// Pool keeps a bounded number of idle runtimes with the shared globals to reuse them between evaluations.
+// The builtins of the pooled runtimes are frozen, and every evaluation gets a fresh global object inheriting
+// the shared one, so that the globals defined by an evaluation are dropped along with its global object.
+type Pool struct {
+	globals       Globals
+	maxStackDepth int
+	runtimes      chan *goja.Runtime
+	shared        sync.Map // Global objects with the shared globals by the runtimes.
+}
+
+// NewPool creates a pool keeping up to the size idle runtimes, the number of CPUs by default.
+// The runtimes fail with a RangeError once the call stack is deeper than the max depth, 1000 calls by default.
+func NewPool(size, maxStackDepth int, globals Globals) *Pool {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+
+	if maxStackDepth <= 0 {
+		maxStackDepth = _defaultMaxStackDepth
+	}
+
+	return &Pool{
+		globals:       globals,
+		maxStackDepth: maxStackDepth,
+		runtimes:      make(chan *goja.Runtime, size),
+		shared:        sync.Map{},
+	}
+}
+
+// Get returns an idle runtime or creates a new one with the frozen builtins if there is none.
+func (p *Pool) Get() (*goja.Runtime, error) {
+	var vm *goja.Runtime
+
+	select {
+	case vm = <-p.runtimes:
+	default:
+		created, err := p.newRuntime()
+		if err != nil {
+			return nil, err
+		}
+
+		vm = created
+	}
+
+	if err := p.isolate(vm); err != nil {
+		p.shared.Delete(vm)
+
+		return nil, err
+	}
+
+	return vm, nil
+}
+
+// Put cleans up the runtime and keeps it for reuse unless there are enough idle runtimes already.
+func (p *Pool) Put(vm *goja.Runtime) {
+	if !p.reset(vm) {
+		return // Drop the runtime which is not of the pool.
+	}
+
+	select {
+	case p.runtimes <- vm:
+	default:
+		p.shared.Delete(vm)
+	}
+}
+
+func (p *Pool) newRuntime() (*goja.Runtime, error) {
+	vm := NewRuntime()
+	vm.SetMaxCallStackSize(p.maxStackDepth)
+
+	// Freeze the builtins before the shared globals are set, so that the globals are not frozen along.
+	if err := harden(vm); err != nil {
+		return nil, err
+	}
+
+	if err := SetGlobals(vm, p.globals); err != nil {
+		return nil, fmt.Errorf("set shared globals: %w", err)
+	}
+
+	// The evaluations define their globals on their own global objects, so the shared one is never changed.
+	if _, err := vm.RunString(`Object.freeze(globalThis)`); err != nil {
+		return nil, fmt.Errorf("freeze shared globals: %w", err)
+	}
+
+	p.shared.Store(vm, vm.GlobalObject())
+
+	return vm, nil
+}
+
+// isolate sets a fresh global object of the evaluation, the shared globals and the builtins are inherited,
+// while the globals defined by the evaluation, e.g. by var declarations, are kept by the new object only.
+func (p *Pool) isolate(vm *goja.Runtime) error {
+	shared, ok := p.shared.Load(vm)
+	if !ok {
+		return errUnknownRuntime
+	}
+
+	global := vm.NewObject()
+	if err := global.SetPrototype(shared.(*goja.Object)); err != nil { //nolint:forcetypeassert // always an object
+		return fmt.Errorf("inherit shared globals: %w", err)
+	}
+
+	// The inherited globalThis is read-only, so define an own one referring to the new object.
+	err := global.DefineDataProperty("globalThis", global, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	if err != nil {
+		return fmt.Errorf("define globalThis: %w", err)
+	}
+
+	vm.SetGlobalObject(global)
+
+	return nil
+}
+
+// reset drops the global object of the evaluation restoring the shared one, there is nothing else to clean up.
+func (p *Pool) reset(vm *goja.Runtime) bool {
+	shared, ok := p.shared.Load(vm)
+	if !ok {
+		return false
+	}
+
+	vm.ClearInterrupt()
+	vm.SetGlobalObject(shared.(*goja.Object)) //nolint:forcetypeassert // always an object
+
+	return true
+}
@@ -0,0 +1,204 @@
+package js
+
+import (
+	"testing"
+)
+
+func TestPoolIsolatesEvaluations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script string // Run by the first evaluation.
+		check  string // Run by the next evaluation of the same runtime, must be true.
+	}{
+		{name: "global variable", script: `var leaked = 1; globalThis.other = 2`, check: `typeof leaked === "undefined" && typeof other === "undefined"`},
+		{name: "object prototype", script: `Object.prototype.polluted = 1`, check: `({}).polluted === undefined`},
+		{name: "array method", script: `Array.prototype.map = () => "polluted"`, check: `[1].map(x => x + 1)[0] === 2`},
+		{name: "builtin binding", script: `JSON = { stringify: () => "polluted" }`, check: `JSON.stringify(1) === "1"`},
+		{name: "namespace method", script: `Math.max = () => 0`, check: `Math.max(1, 2) === 2`},
+		{name: "builtin function", script: `Math.max.polluted = 1`, check: `Math.max.polluted === undefined`},
+		{name: "deleted method", script: `delete String.prototype.trim`, check: `" a ".trim() === "a"`},
+		{name: "iterator prototype", script: `Object.getPrototypeOf([][Symbol.iterator]()).next = () => ({ done: true })`, check: `[...[1, 2]].length === 2`}, //nolint:lll
+		{name: "prototype swap", script: `Object.setPrototypeOf(Array.prototype, { polluted: 1 })`, check: `[].polluted === undefined`},
+		{name: "shared global", script: `shared = null`, check: `shared.value === 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewPool(1, 0, Globals{"shared": map[string]any{"value": 1}})
+
+			vm, err := pool.Get()
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			_, _ = vm.RunString(tt.script) // Some changes are rejected with an error, which is fine.
+			pool.Put(vm)
+
+			vm, err = pool.Get()
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			got, err := vm.RunString(tt.check)
+			if err != nil {
+				t.Fatalf("check error = %v", err)
+			}
+
+			if !got.ToBoolean() {
+				t.Errorf("%s is false after %s", tt.check, tt.script)
+			}
+		})
+	}
+}
+
+func TestPoolKeepsScriptsWorking(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script string // Must be true.
+	}{
+		{name: "override toString", script: `function F() {}; F.prototype.toString = function () { return "F" }; String(new F()) === "F"`},                   //nolint:lll
+		{name: "override error name", script: `function E() {}; E.prototype = Object.create(Error.prototype); E.prototype.name = "E"; new E().name === "E"`}, //nolint:lll
+		{name: "own toString", script: `const o = { toString: () => "o" }; o.toString = () => "p"; String(o) === "p"`},
+		{name: "builtin toString", script: `({}).toString() === "[object Object]" && String([1, 2]) === "1,2"`},
+		{name: "class methods", script: `class A { toString() { return "A" } }; String(new A()) === "A"`},
+		{name: "generators", script: `function* g() { yield 1; yield 2 }; [...g()].join() === "1,2"`},
+		{name: "promises", script: `Promise.resolve(1) instanceof Promise`},
+		{name: "global variable", script: `var x = 1; x === 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			vm, err := NewPool(1, 0, nil).Get()
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			got, err := vm.RunString(tt.script)
+			if err != nil {
+				t.Fatalf("script error = %v", err)
+			}
+
+			if !got.ToBoolean() {
+				t.Errorf("%s is false", tt.script)
+			}
+		})
+	}
+}
+
+func TestPoolResetsLeakedGlobals(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script string // Compiled as a mock script, leaks the global on every run.
+		check  string // Run by the next evaluation of the same runtime, must be true.
+	}{
+		{name: "var declaration", script: `var leaked = 1`, check: `typeof leaked === "undefined"`},
+		{name: "implicit global", script: `leaked = 1`, check: `typeof leaked === "undefined"`},
+		{name: "global this property", script: `globalThis.leaked = 1`, check: `typeof leaked === "undefined"`},
+		{name: "function declaration", script: `function leaked() {}`, check: `typeof leaked === "undefined"`},
+		{name: "let declaration", script: `let leaked = 1`, check: `typeof leaked === "undefined"`},
+		{name: "overwritten shared global", script: `shared = 1`, check: `shared.value === 1`},
+		{name: "deleted shared global", script: `delete globalThis.shared`, check: `shared.value === 1`},
+		{name: "shared global object", script: `const shared = Object.getPrototypeOf(globalThis); shared.leaked = 1; shared.shared = 1`, check: `typeof leaked === "undefined" && shared.value === 1`}, //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewPool(1, 0, Globals{"shared": map[string]any{"value": 1}})
+
+			program, err := Compile("leak.js", tt.script)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			// Run the script twice, so that its declarations don't clash with the leaked ones either.
+			for range 2 {
+				vm, err := pool.Get()
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+
+				if _, err = vm.RunProgram(program); err != nil {
+					t.Fatalf("script error = %v", err)
+				}
+
+				pool.Put(vm)
+
+				vm, err = pool.Get()
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+
+				got, err := vm.RunString(tt.check)
+				if err != nil {
+					t.Fatalf("check error = %v", err)
+				}
+
+				if !got.ToBoolean() {
+					t.Errorf("%s is false after %s", tt.check, tt.script)
+				}
+
+				pool.Put(vm)
+			}
+		})
+	}
+}
+
+// BenchmarkPool compares evaluating a script in a new runtime compiling it on every call
+// with evaluating the compiled script in a pooled runtime.
+func BenchmarkPool(b *testing.B) {
+	const script = `const user = { id: request.id, name: "John" };
+({ status: 200, body: user })`
+
+	request := map[string]any{"id": 42}
+
+	b.Run("new runtime", func(b *testing.B) {
+		for b.Loop() {
+			vm := NewRuntime()
+			if err := vm.Set("request", request); err != nil {
+				b.Fatalf("Set() error = %v", err)
+			}
+
+			if _, err := vm.RunString(script); err != nil {
+				b.Fatalf("RunString() error = %v", err)
+			}
+		}
+	})
+
+	b.Run("pooled runtime", func(b *testing.B) {
+		pool := NewPool(1, 0, nil)
+
+		program, err := Compile("bench.js", script)
+		if err != nil {
+			b.Fatalf("Compile() error = %v", err)
+		}
+
+		for b.Loop() {
+			vm, err := pool.Get()
+			if err != nil {
+				b.Fatalf("Get() error = %v", err)
+			}
+
+			if err = vm.Set("request", request); err != nil {
+				b.Fatalf("Set() error = %v", err)
+			}
+
+			if _, err = vm.RunProgram(program); err != nil {
+				b.Fatalf("RunProgram() error = %v", err)
+			}
+
+			pool.Put(vm)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package js
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// Compile compiles a script to be run by pooled runtimes. The script is evaluated inside a block,
+// so that its top-level let, const and function declarations don't outlive a run, while the completion value
+// of the script is still the result of the run.
+func Compile(file, script string) (*goja.Program, error) {
+	return compile(file, "{"+script+"\n}") // Keep the line numbers as is.
+}
+
+// CompileHandlers compiles a script defining the named handler functions, e.g. onMessage, to be run by pooled
+// runtimes. The script is evaluated inside a function, so that its top-level declarations are kept by the handlers
+// only, and the result of the run is an object of the handlers defined by the script, a missing one is undefined.
+func CompileHandlers(file, script string, names ...string) (*goja.Program, error) {
+	handlers := make([]string, 0, len(names))
+	for _, name := range names {
+		handlers = append(handlers, fmt.Sprintf(`%[1]s: typeof %[1]s === "function" ? %[1]s : undefined`, name))
+	}
+
+	// Keep the line numbers as is.
+	return compile(file, "(function () {"+script+"\n;return { "+strings.Join(handlers, ", ")+" };\n})()")
+}
+
+func compile(file, script string) (*goja.Program, error) {
+	program, err := goja.Compile(file, script, false)
+	if err != nil {
+		return nil, fmt.Errorf("compile script: %w", err)
+	}
+
+	return program, nil
+}
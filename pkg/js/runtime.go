@@ -1,6 +1,14 @@
 package js
 
-import "github.com/dop251/goja"
+import (
+	"context"
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+// Globals are the global variables set in a runtime by their names.
+type Globals map[string]any
 
 func NewRuntime() *goja.Runtime {
 	vm := goja.New()
@@ -8,3 +16,32 @@ func NewRuntime() *goja.Runtime {
 
 	return vm
 }
+
+// SetGlobals sets the global variables in the runtime.
+func SetGlobals(vm *goja.Runtime, globals Globals) error {
+	for name, value := range globals {
+		if err := vm.Set(name, value); err != nil {
+			return fmt.Errorf("set %s in runtime: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// SetCallGlobals sets the console logging to the call context, the require of the modules
+// and the call specific globals, e.g. the request, in the runtime.
+func SetCallGlobals(ctx context.Context, vm *goja.Runtime, modules *Modules, globals Globals) error {
+	if err := vm.Set("console", NewConsole(ctx)); err != nil {
+		return fmt.Errorf("set console in runtime: %w", err)
+	}
+
+	if err := vm.Set("require", modules.Require(vm)); err != nil {
+		return fmt.Errorf("set require in runtime: %w", err)
+	}
+
+	if err := SetGlobals(vm, globals); err != nil {
+		return fmt.Errorf("set call globals in runtime: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package conntrack
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sknv/protomock/pkg/option"
+)
+
+// Tracker keeps the open connections of listeners by their remote addresses,
+// so that a connection can be found by the peer address of a request.
+type Tracker struct {
+	mu    sync.RWMutex
+	conns map[string]*Conn
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		mu:    sync.RWMutex{},
+		conns: make(map[string]*Conn),
+	}
+}
+
+// Listener wraps the listener to track the accepted connections until they are closed.
+func (t *Tracker) Listener(lis net.Listener) net.Listener {
+	return &listener{
+		Listener: lis,
+		tracker:  t,
+	}
+}
+
+// Lookup returns the open connection with the remote address.
+func (t *Tracker) Lookup(addr net.Addr) option.Option[*Conn] {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if conn, ok := t.conns[addr.String()]; ok {
+		return option.Some(conn)
+	}
+
+	return option.None[*Conn]()
+}
+
+func (t *Tracker) add(conn *Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.conns[conn.RemoteAddr().String()] = conn
+}
+
+func (t *Tracker) remove(conn *Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The address may be reused by a newer connection, e.g. by in-memory listeners.
+	if key := conn.RemoteAddr().String(); t.conns[key] == conn {
+		delete(t.conns, key)
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+type listener struct {
+	net.Listener
+
+	tracker *Tracker
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // proxy
+	}
+
+	tracked := &Conn{
+		Conn:     conn,
+		tracker:  l.tracker,
+		once:     sync.Once{},
+		mu:       sync.Mutex{},
+		throttle: nil,
+	}
+	l.tracker.add(tracked)
+
+	return tracked, nil
+}
+
+// Conn is a tracked connection, its writes can be throttled on purpose.
+type Conn struct {
+	net.Conn
+
+	tracker  *Tracker
+	once     sync.Once
+	mu       sync.Mutex
+	throttle *throttle
+}
+
+// throttle splits the writes into chunks with pauses in between.
+type throttle struct {
+	chunkSize int
+	pause     time.Duration
+	released  bool // Lifted after the next write.
+}
+
+// Throttle splits the following writes into chunks of the size with the pause in between
+// until the returned release is called. The throttle is lifted after the next write completes,
+// so that the data queued before the release, e.g. by a buffered writer, is throttled as well.
+func (c *Conn) Throttle(chunkSize int, pause time.Duration) func() {
+	thr := &throttle{
+		chunkSize: max(chunkSize, 1),
+		pause:     pause,
+		released:  false,
+	}
+
+	c.mu.Lock()
+	c.throttle = thr
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		thr.released = true
+	}
+}
+
+func (c *Conn) Write(data []byte) (int, error) {
+	c.mu.Lock()
+	thr := c.throttle
+	released := thr != nil && thr.released
+	c.mu.Unlock()
+
+	if thr == nil {
+		return c.Conn.Write(data) //nolint:wrapcheck // proxy
+	}
+
+	if released {
+		defer c.lift(thr)
+	}
+
+	var written int
+
+	for len(data) > 0 {
+		if written > 0 {
+			time.Sleep(thr.pause)
+		}
+
+		chunk := data[:min(thr.chunkSize, len(data))]
+
+		n, err := c.Conn.Write(chunk)
+		written += n
+
+		if err != nil {
+			return written, err //nolint:wrapcheck // proxy
+		}
+
+		data = data[n:]
+	}
+
+	return written, nil
+}
+
+// lift removes the throttle unless it has been replaced already.
+func (c *Conn) lift(thr *throttle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.throttle == thr {
+		c.throttle = nil
+	}
+}
+
+func (c *Conn) Close() error {
+	c.once.Do(func() { c.tracker.remove(c) })
+
+	return c.Conn.Close() //nolint:wrapcheck // proxy
+}
+
+// NetConn returns the underlying connection, e.g. to reset it.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
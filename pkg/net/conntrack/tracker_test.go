@@ -0,0 +1,76 @@
+package conntrack
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	lis = tracker.Listener(lis)
+	t.Cleanup(func() { _ = lis.Close() })
+
+	client, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = client.Close() })
+
+	server, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+
+	conn := tracker.Lookup(client.LocalAddr())
+	if conn.IsNone() || conn.Unwrap() != server {
+		t.Fatalf("Lookup() = %v, want the accepted connection", conn)
+	}
+
+	// The throttled writes are split into chunks until the next write after the release.
+	release := conn.Unwrap().Throttle(2, time.Millisecond*20)
+	release()
+
+	start := time.Now()
+
+	if _, err = server.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Millisecond*40 {
+		t.Errorf("throttled Write() took %s, want at least %s", elapsed, time.Millisecond*40)
+	}
+
+	start = time.Now()
+
+	if _, err = server.Write([]byte("world")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Millisecond*20 {
+		t.Errorf("released Write() took %s, want less than %s", elapsed, time.Millisecond*20)
+	}
+
+	got := make([]byte, 10)
+	if _, err = io.ReadFull(client, got); err != nil || string(got) != "helloworld" {
+		t.Fatalf("ReadFull() = %q, %v, want %q", got, err, "helloworld")
+	}
+
+	if err = server.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if conn = tracker.Lookup(client.LocalAddr()); conn.IsSome() {
+		t.Errorf("Lookup() after Close() = %v, want none", conn)
+	}
+}
@@ -0,0 +1,30 @@
+package dynamic
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// AnyToMap unpacks a message from Any returning its full type name and data.
+func AnyToMap(resolver TypeResolver, anyMsg *anypb.Any) (string, map[string]any, error) {
+	msg, err := anypb.UnmarshalNew(anyMsg, proto.UnmarshalOptions{Resolver: resolver}) //nolint:exhaustruct // only resolver is required
+	if err != nil {
+		return "", nil, fmt.Errorf("unpack message %s from any: %w", anyMsg.GetTypeUrl(), err)
+	}
+
+	jsonData, err := (protojson.MarshalOptions{Resolver: resolver}).Marshal(msg) //nolint:exhaustruct // only resolver is required
+	if err != nil {
+		return "", nil, fmt.Errorf("encode proto message to json: %w", err)
+	}
+
+	var data map[string]any
+	if err = json.Unmarshal(jsonData, &data); err != nil {
+		return "", nil, fmt.Errorf("decode data from json: %w", err)
+	}
+
+	return string(msg.ProtoReflect().Descriptor().FullName()), data, nil
+}
@@ -0,0 +1,42 @@
+package dynamic
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// TypeResolver looks up message and extension types by their names.
+type TypeResolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// MapToAny builds a message of the named type and packs it into Any.
+func MapToAny(resolver TypeResolver, typeName string, data map[string]any) (*anypb.Any, error) {
+	msgType, err := resolver.FindMessageByName(protoreflect.FullName(typeName))
+	if err != nil {
+		return nil, fmt.Errorf("find message type %s: %w", typeName, err)
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode data to json: %w", err)
+	}
+
+	msg := msgType.New().Interface()
+	if err = (protojson.UnmarshalOptions{Resolver: resolver}).Unmarshal(jsonData, msg); err != nil { //nolint:exhaustruct // only resolver is required
+		return nil, fmt.Errorf("decode proto message from json: %w", err)
+	}
+
+	anyMsg, err := anypb.New(msg)
+	if err != nil {
+		return nil, fmt.Errorf("pack message to any: %w", err)
+	}
+
+	return anyMsg, nil
+}
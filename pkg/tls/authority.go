@@ -0,0 +1,195 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	CACertFile = "ca.crt"
+	CAKeyFile  = "ca.key"
+
+	_caValidity     = time.Hour * 24 * 365 * 10
+	_serverValidity = time.Hour * 24 * 365
+	_serialBits     = 128
+)
+
+var errInvalidPEM = errors.New("invalid pem")
+
+// Authority is a self-signed certificate authority issuing the server certificates in the auto mode.
+type Authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// LoadOrCreateAuthority loads the authority from the dir or generates a new one and writes it to the dir,
+// so that the clients keep trusting it across restarts. The CA key may also be used to issue client certificates.
+func LoadOrCreateAuthority(dir string) (*Authority, error) {
+	certFile, keyFile := filepath.Join(dir, CACertFile), filepath.Join(dir, CAKeyFile)
+
+	if _, err := os.Stat(certFile); err == nil {
+		return loadAuthority(certFile, keyFile)
+	}
+
+	authority, err := newAuthority()
+	if err != nil {
+		return nil, fmt.Errorf("generate authority: %w", err)
+	}
+
+	if err = authority.save(dir, certFile, keyFile); err != nil {
+		return nil, fmt.Errorf("save authority: %w", err)
+	}
+
+	return authority, nil
+}
+
+// NewServerConfig issues a server certificate for the local hosts and builds a server config with it,
+// see NewServerConfig for the client CA.
+func (a *Authority) NewServerConfig(clientCAFile string) (*tls.Config, error) {
+	cert, err := a.issueServerCert()
+	if err != nil {
+		return nil, fmt.Errorf("issue server certificate: %w", err)
+	}
+
+	return newServerConfig(cert, clientCAFile)
+}
+
+// ----------------------------------------------------------------------------
+
+func newAuthority() (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{ //nolint:exhaustruct // too many unused fields
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "protomock CA"}, //nolint:exhaustruct // only a name
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(_caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("create certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %w", err)
+	}
+
+	return &Authority{
+		cert: cert,
+		key:  key,
+	}, nil
+}
+
+func loadAuthority(certFile, keyFile string) (*Authority, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an ecdsa key", errInvalidPEM, keyFile)
+	}
+
+	return &Authority{
+		cert: pair.Leaf,
+		key:  key,
+	}, nil
+}
+
+func (a *Authority) save(dir, certFile, keyFile string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd // rwxr-xr-x
+		return fmt.Errorf("create dir: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(a.key)
+	if err != nil {
+		return fmt.Errorf("encode key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: nil, Bytes: a.cert.Raw})
+	if err = os.WriteFile(certFile, certPEM, 0o644); err != nil { //nolint:gosec,mnd // public certificate
+		return fmt.Errorf("write certificate: %w", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Headers: nil, Bytes: keyDER})
+	if err = os.WriteFile(keyFile, keyPEM, 0o600); err != nil { //nolint:mnd // rw-------
+		return fmt.Errorf("write key: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Authority) issueServerCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generate key: %w", err)
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	dnsNames := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "localhost" {
+		dnsNames = append(dnsNames, hostname)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{ //nolint:exhaustruct // too many unused fields
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "protomock"}, //nolint:exhaustruct // only a name
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(_serverValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}, //nolint:mnd // localhost
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("create certificate: %w", err)
+	}
+
+	return tls.Certificate{ //nolint:exhaustruct // too many unused fields
+		Certificate: [][]byte{der, a.cert.Raw},
+		PrivateKey:  key,
+	}, nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), _serialBits))
+	if err != nil {
+		return nil, fmt.Errorf("generate serial number: %w", err)
+	}
+
+	return serial, nil
+}
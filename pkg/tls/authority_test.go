@@ -0,0 +1,102 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateAuthority(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "tls") // Created on the first start.
+
+	created, err := LoadOrCreateAuthority(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateAuthority() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		t.Fatalf("stat key error = %v", err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key permissions = %o, want %o", perm, 0o600)
+	}
+
+	// A restart loads the same authority instead of generating a new one.
+	loaded, err := LoadOrCreateAuthority(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateAuthority() reload error = %v", err)
+	}
+
+	if !bytes.Equal(loaded.cert.Raw, created.cert.Raw) || !loaded.key.Equal(created.key) {
+		t.Fatal("LoadOrCreateAuthority() reload returned another authority")
+	}
+
+	// The server certificates issued after the restart are trusted by the clients of the saved CA.
+	caPEM, err := os.ReadFile(filepath.Join(dir, CACertFile))
+	if err != nil {
+		t.Fatalf("read ca error = %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		t.Fatal("append ca error")
+	}
+
+	config, err := loaded.NewServerConfig("")
+	if err != nil {
+		t.Fatalf("NewServerConfig() error = %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("parse server certificate error = %v", err)
+	}
+
+	//nolint:exhaustruct // only the trusted roots and the host
+	if _, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "localhost"}); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestLoadOrCreateAuthorityInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		key  string // Content of the key file, the certificate is valid.
+	}{
+		{name: "missing key", key: ""},
+		{name: "invalid key", key: "not a key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if _, err := LoadOrCreateAuthority(dir); err != nil {
+				t.Fatalf("LoadOrCreateAuthority() error = %v", err)
+			}
+
+			keyFile := filepath.Join(dir, CAKeyFile)
+			if tt.key == "" {
+				if err := os.Remove(keyFile); err != nil {
+					t.Fatalf("remove key error = %v", err)
+				}
+			} else if err := os.WriteFile(keyFile, []byte(tt.key), 0o600); err != nil {
+				t.Fatalf("write key error = %v", err)
+			}
+
+			// A broken authority fails the start rather than being silently replaced.
+			if _, err := LoadOrCreateAuthority(dir); err == nil {
+				t.Error("LoadOrCreateAuthority() error = nil, want an error")
+			}
+		})
+	}
+}
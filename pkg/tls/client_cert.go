@@ -0,0 +1,47 @@
+package tls
+
+import (
+	"crypto/tls"
+)
+
+// ClientCert describes a client certificate exposed to the mock scripts.
+type ClientCert struct {
+	Subject        string   `json:"subject"` // Distinguished name, e.g. CN=client,O=Example.
+	CommonName     string   `json:"commonName"`
+	Issuer         string   `json:"issuer"`
+	SerialNumber   string   `json:"serialNumber"`
+	DNSNames       []string `json:"dnsNames"`
+	EmailAddresses []string `json:"emailAddresses"`
+	IPAddresses    []string `json:"ipAddresses"`
+	URIs           []string `json:"uris"`
+}
+
+// NewClientCert returns the verified client certificate of the connection if there is one.
+func NewClientCert(state *tls.ConnectionState) *ClientCert {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := state.VerifiedChains[0][0]
+
+	ipAddresses := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ipAddresses = append(ipAddresses, ip.String())
+	}
+
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	return &ClientCert{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		Issuer:         cert.Issuer.String(),
+		SerialNumber:   cert.SerialNumber.String(),
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		IPAddresses:    ipAddresses,
+		URIs:           uris,
+	}
+}
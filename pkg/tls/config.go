@@ -0,0 +1,59 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var errNoCertificates = errors.New("no certificates found")
+
+// NewServerConfig builds a server config from the PEM encoded certificate and key files.
+// Clients must provide a certificate signed by the client CA if the file is set, i.e. mutual TLS is enabled.
+func NewServerConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+
+	return newServerConfig(cert, clientCAFile)
+}
+
+// ----------------------------------------------------------------------------
+
+func newServerConfig(cert tls.Certificate, clientCAFile string) (*tls.Config, error) {
+	config := &tls.Config{ //nolint:exhaustruct // too many unused fields
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile == "" {
+		return config, nil
+	}
+
+	clientCAs, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("load client ca: %w", err)
+	}
+
+	config.ClientCAs = clientCAs
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return config, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w in %s", errNoCertificates, file)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,91 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sknv/protomock/pkg/log"
+)
+
+// Handler is called when the watched directories change.
+type Handler func(ctx context.Context)
+
+// Watch polls the directory trees every interval and calls the handler when any file is added,
+// removed or modified. Polling works for mounted volumes where file system events are not delivered.
+// A missing directory is watched as an empty one, so that creating it is noticed as well.
+// Blocks until the context is done.
+func Watch(ctx context.Context, dirs []string, interval time.Duration, handler Handler) {
+	last, err := fingerprint(dirs)
+	if err != nil {
+		log.FromContext(ctx).WarnContext(ctx, "Can't scan watched directories",
+			slog.Any("dirs", dirs), slog.Any("error", err))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := fingerprint(dirs)
+		if err != nil {
+			log.FromContext(ctx).WarnContext(ctx, "Can't scan watched directories",
+				slog.Any("dirs", dirs), slog.Any("error", err))
+
+			continue
+		}
+
+		if current == last {
+			continue
+		}
+
+		last = current
+		handler(ctx)
+	}
+}
+
+// fingerprint hashes the names, sizes and modification times of all the files in the directory trees.
+func fingerprint(dirs []string) (uint64, error) {
+	hash := fnv.New64a()
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("traverse path: %w", err)
+			}
+
+			if entry.IsDir() {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return fmt.Errorf("get file info: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(hash, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("walk dir %s: %w", dir, err)
+		}
+	}
+
+	return hash.Sum64(), nil
+}